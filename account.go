@@ -1,38 +1,38 @@
 package gofile
 
 import (
+	"context"
+
 	"github.com/dvwzj/gofile/entity"
-	"github.com/go-resty/resty/v2"
 )
 
-func NewGuestAccount() (*entity.CreatedAccount, error) {
-	createdAccount, err := RegisterNewAccount("")
+func NewGuestAccount(options ...ClientOption) (*entity.CreatedAccount, error) {
+	return NewGuestAccountContext(context.Background(), options...)
+}
+
+func NewGuestAccountContext(ctx context.Context, options ...ClientOption) (*entity.CreatedAccount, error) {
+	createdAccount, err := RegisterNewAccountContext(ctx, "", options...)
 	if err != nil {
 		return nil, err
 	}
 	return createdAccount, nil
 }
 
-func RegisterNewAccount(email string) (*entity.CreatedAccount, error) {
-	httpCLient := resty.New()
-	req := httpCLient.
-		R().
-		SetError(entity.Response[entity.CreatedAccount]{}).
-		SetResult(entity.Response[entity.CreatedAccount]{})
-	if email != "" {
-		req.SetBody(map[string]interface{}{
-			"email": email,
-		})
-	}
-	resp, err := req.Post("https://api.gofile.io/accounts")
+func RegisterNewAccount(email string, options ...ClientOption) (*entity.CreatedAccount, error) {
+	return RegisterNewAccountContext(context.Background(), email, options...)
+}
+
+func RegisterNewAccountContext(ctx context.Context, email string, options ...ClientOption) (*entity.CreatedAccount, error) {
+	client, err := NewClient(options...)
 	if err != nil {
 		return nil, err
 	}
-	if resp.IsError() {
-		return nil, resp.Error().(*entity.Response[entity.CreatedAccount]).Error()
+	createdAccount, err := client.CreateAccountContext(ctx, email)
+	if err != nil {
+		return nil, err
 	}
-	if createdAccount, ok := resp.Result().(*entity.Response[entity.CreatedAccount]); ok {
-		return &createdAccount.Data, nil
+	if createdAccount.Token == "" {
+		return nil, entity.ErrAccount
 	}
-	return nil, entity.ErrAccount
+	return createdAccount, nil
 }
@@ -1,7 +1,12 @@
 package gofile
 
 import (
+	"errors"
+	"time"
+
+	"github.com/dvwzj/gofile/domain/api"
 	"github.com/dvwzj/gofile/entity"
+	"github.com/dvwzj/gofile/selector"
 	"github.com/dvwzj/gofile/services"
 	"github.com/go-resty/resty/v2"
 )
@@ -33,6 +38,78 @@ func WithToken(token string) ClientOption {
 	}
 }
 
+func WithBaseURL(baseURL string) ClientOption {
+	return func(client Client) error {
+		if baseURL == "" {
+			return errors.New("baseURL is empty")
+		}
+		client.API().SetBaseURL(baseURL)
+		return nil
+	}
+}
+
+// WithUploadURLTemplate sets the root url of the upload servers,
+// "{server}" is replaced by the server name (e.g. "https://{server}.gofile.io").
+func WithUploadURLTemplate(uploadURLTemplate string) ClientOption {
+	return func(client Client) error {
+		if uploadURLTemplate == "" {
+			return errors.New("uploadURLTemplate is empty")
+		}
+		client.API().SetUploadURLTemplate(uploadURLTemplate)
+		return nil
+	}
+}
+
+// WithRetryPolicy retries transient failures (5xx, rate limits, network errors)
+// with an exponential backoff, see api.DefaultRetryPolicy.
+func WithRetryPolicy(policy api.RetryPolicy) ClientOption {
+	return func(client Client) error {
+		client.API().SetRetryPolicy(policy)
+		return nil
+	}
+}
+
+// WithServerSelector sets how the upload server is chosen when UploadFile
+// is not given params.WithServerName, the default is selector.Random().
+func WithServerSelector(serverSelector selector.ServerSelector) ClientOption {
+	return func(client Client) error {
+		if serverSelector == nil {
+			return errors.New("serverSelector is nil")
+		}
+		client.API().SetServerSelector(serverSelector)
+		return nil
+	}
+}
+
+// WithServerCache reuses the server list and the successful server probes for ttl
+// instead of fetching and probing them on every upload.
+func WithServerCache(ttl time.Duration) ClientOption {
+	return func(client Client) error {
+		client.API().SetServerCacheTTL(ttl)
+		return nil
+	}
+}
+
+// WithServerCooldown skips an upload server for cooldown after it failed a probe
+// or an upload, it is probed again afterwards.
+func WithServerCooldown(cooldown time.Duration) ClientOption {
+	return func(client Client) error {
+		client.API().SetServerCooldown(cooldown)
+		return nil
+	}
+}
+
+// WithUploadFailover is params.WithFailover for every upload of the client.
+func WithUploadFailover(maxServers int) ClientOption {
+	return func(client Client) error {
+		if maxServers < 1 {
+			return errors.New("maxServers must be at least 1")
+		}
+		client.API().SetUploadFailover(maxServers)
+		return nil
+	}
+}
+
 func WithAccount(account *entity.Account) ClientOption {
 	return func(client Client) error {
 		if account == nil {
@@ -46,11 +123,16 @@ func WithAccount(account *entity.Account) ClientOption {
 	}
 }
 
+// WithNewGuestAccount registers a guest account through the client itself,
+// so it must come after WithBaseURL when both are used.
 func WithNewGuestAccount(client Client) error {
-	createdAccount, err := NewGuestAccount()
+	createdAccount, err := client.CreateAccount("")
 	if err != nil {
 		return err
 	}
+	if createdAccount.Token == "" {
+		return entity.ErrAccount
+	}
 	client.HttpClient().SetAuthToken(createdAccount.Token)
 	return nil
 }
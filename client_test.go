@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/dvwzj/gofile"
+	"github.com/dvwzj/gofile/gofiletest"
 )
 
 func TestNewClient(t *testing.T) {
@@ -27,11 +28,36 @@ func TestNewClientWithToken(t *testing.T) {
 }
 
 func TestNewClientWithNewGuestAccount(t *testing.T) {
-	client, err := gofile.NewClient(gofile.WithNewGuestAccount)
+	server := gofiletest.NewServer()
+	defer server.Close()
+	client, err := server.NewClient(gofile.WithNewGuestAccount)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	if client == nil {
 		t.Fatalf("unexpected nil client")
 	}
+	if client.GetToken() == "" {
+		t.Fatalf("unexpected empty token")
+	}
+}
+
+func TestNewClientWithBaseURL(t *testing.T) {
+	_, err := gofile.NewClient(gofile.WithBaseURL(""))
+	if err == nil {
+		t.Fatalf("unexpected nil error")
+	}
+	_, err = gofile.NewClient(gofile.WithUploadURLTemplate(""))
+	if err == nil {
+		t.Fatalf("unexpected nil error")
+	}
+	server := gofiletest.NewServer()
+	defer server.Close()
+	createdAccount, err := gofile.NewGuestAccount(server.ClientOptions()...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := server.Account(createdAccount.Id); !ok {
+		t.Fatalf("account %s not registered on the test server", createdAccount.Id)
+	}
 }
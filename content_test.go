@@ -0,0 +1,55 @@
+package gofile_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dvwzj/gofile"
+	"github.com/dvwzj/gofile/entity"
+	"github.com/dvwzj/gofile/gofiletest"
+	"github.com/dvwzj/gofile/params"
+)
+
+func TestGetContentPassword(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	owner := server.NewAccount(entity.AccountTierPremium)
+	ownerClient, err := server.NewClient(gofile.WithToken(owner.Token))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reader := server.NewAccount(entity.AccountTierPremium)
+	client, err := server.NewClient(gofile.WithToken(reader.Token))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	folder, err := ownerClient.CreateFolder(owner.RootFolder)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, option := range []params.UpdateContentOption{params.WithPublic(true), params.WithPassword("s3cret")} {
+		if err := ownerClient.UpdateContent(folder.FolderId, option); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if _, err := ownerClient.GetContent(folder.FolderId); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the status is "ok", the password status is in the data
+	var apiErr *entity.APIError
+	if _, err := client.GetContent(folder.FolderId); !errors.Is(err, entity.ErrPasswordRequired) || errors.As(err, &apiErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.GetContent(folder.FolderId, params.WithContentPassword("secret")); !errors.Is(err, entity.ErrWrongPassword) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := client.GetContent(folder.FolderId, params.WithContentPassword("s3cret"))
+	if err != nil || content.Id != folder.FolderId || !content.Password || content.PasswordStatus != "passwordOk" {
+		t.Fatalf("unexpected content: %+v, %v", content, err)
+	}
+	content, err = client.ResolveShareURL(server.URL+"/d/"+folder.Code, params.WithContentPassword("s3cret"))
+	if err != nil || content.Id != folder.FolderId {
+		t.Fatalf("unexpected content: %+v, %v", content, err)
+	}
+}
@@ -0,0 +1,59 @@
+package gofile_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dvwzj/gofile"
+	"github.com/dvwzj/gofile/entity"
+	"github.com/dvwzj/gofile/gofiletest"
+	"github.com/dvwzj/gofile/params"
+)
+
+func TestContextCancel(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	account := server.NewAccount(entity.AccountTierPremium)
+	client, err := server.NewClient(gofile.WithToken(account.Token))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// cancel returns a context canceled while its request hangs on the server
+	cancel := func() context.Context {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+		return ctx
+	}
+
+	server.BlockNext(http.MethodGet, "/contents/"+account.RootFolder, 1)
+	if _, err := client.GetContentContext(cancel(), account.RootFolder); !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	server.BlockNext(http.MethodPost, "/store/store1/contents/uploadfile", 1)
+	_, err = client.UploadFileContext(cancel(), params.WithBytes([]byte("data"), "data.txt"), params.WithServerName("store1"))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the server selection probes the servers with HEAD requests
+	server.BlockNext(http.MethodHead, "/store/store1", 1)
+	server.BlockNext(http.MethodHead, "/store/store2", 1)
+	_, err = client.UploadFileContext(cancel(), params.WithBytes([]byte("data"), "data.txt"))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, stop := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer stop()
+	server.BlockNext(http.MethodGet, "/contents/"+account.RootFolder, 1)
+	if _, err := client.GetContentContext(ctx, account.RootFolder); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if server.Requests(http.MethodPost, "/store/store2/contents/uploadfile") != 0 {
+		t.Fatalf("a canceled upload was sent to another server")
+	}
+}
@@ -1,204 +1,206 @@
 package api
 
 import (
+	"context"
 	"fmt"
-	"math/rand"
+	"net/http"
 	"strings"
-	"sync"
+	"time"
 
 	"github.com/dvwzj/gofile/entity"
 	"github.com/dvwzj/gofile/params"
+	"github.com/dvwzj/gofile/selector"
 	"github.com/go-resty/resty/v2"
 )
 
+const (
+	DefaultBaseURL           = "https://api.gofile.io"
+	DefaultUploadURLTemplate = "https://{server}.gofile.io"
+)
+
 type API interface {
 	HttpClient() *resty.Client
+	SetBaseURL(baseURL string)
+	SetUploadURLTemplate(uploadURLTemplate string)
+	UploadURL(server string) string
+	SetRetryPolicy(policy RetryPolicy)
+	SetServerSelector(serverSelector selector.ServerSelector)
+	SetServerCacheTTL(ttl time.Duration)
+	SetServerCooldown(cooldown time.Duration)
+	SetUploadFailover(maxServers int)
+	OpenLink(link string, offset int64) (*http.Response, error)
+	OpenLinkContext(ctx context.Context, link string, offset int64) (*http.Response, error)
+	OpenLinkRange(link string, offset, length int64) (*http.Response, error)
+	OpenLinkRangeContext(ctx context.Context, link string, offset, length int64) (*http.Response, error)
+	ServerHealth() []entity.ServerHealth
+	SelectServers(serverSelector selector.ServerSelector) ([]selector.Candidate, error)
+	SelectServersContext(ctx context.Context, serverSelector selector.ServerSelector) ([]selector.Candidate, error)
 	Repository
 }
 
 type Domain struct {
-	httpClient *resty.Client
+	httpClient        *resty.Client
+	uploadURLTemplate string
+	retryPolicy       *RetryPolicy
+	serverSelector    selector.ServerSelector
+	serverState       *serverState
+	uploadFailover    int
 }
 
 func (d *Domain) HttpClient() *resty.Client {
 	return d.httpClient
 }
 
+func (d *Domain) SetBaseURL(baseURL string) {
+	d.httpClient.SetBaseURL(baseURL)
+}
+
+func (d *Domain) SetUploadURLTemplate(uploadURLTemplate string) {
+	d.uploadURLTemplate = uploadURLTemplate
+}
+
+// UploadURL returns the root url of an upload server, "{server}" in the
+// upload url template is replaced by the server name.
+func (d *Domain) UploadURL(server string) string {
+	return strings.ReplaceAll(d.uploadURLTemplate, "{server}", server)
+}
+
 func (d Domain) GetServers() (*entity.Response[entity.Servers], error) {
-	resp, err := d.httpClient.R().
-		SetError(entity.Response[entity.Servers]{}).
-		SetResult(entity.Response[entity.Servers]{}).
-		Get("/servers")
-	if err != nil {
-		return nil, err
-	}
-	if resp.IsError() {
-		return nil, resp.Error().(*entity.Response[entity.Servers]).Error()
-	}
-	return resp.Result().(*entity.Response[entity.Servers]), nil
+	return d.GetServersContext(context.Background())
 }
 
-func (d Domain) UploadFile(file params.UploadFile, options ...params.UploadFileOption) (*entity.Response[entity.UploadedFile], error) {
-	params := &params.UploadFileParams{}
-	if err := file(params); err != nil {
-		return nil, err
-	}
-	for _, option := range options {
-		if err := option(params); err != nil {
-			return nil, err
-		}
-	}
-	server := ""
-	if params.Server != nil {
-		server = *params.Server
-	} else {
-		serversResp, err := d.GetServers()
-		if err != nil {
-			return nil, err
-		}
-		if len(serversResp.Data.Servers) == 0 {
-			return nil, fmt.Errorf("no server available")
-		}
-		avaiableServers := make(chan string, len(serversResp.Data.Servers))
-		errCh := make(chan error, len(serversResp.Data.Servers))
-		wg := sync.WaitGroup{}
-		for _, server := range serversResp.Data.Servers {
-			wg.Add(1)
-			go func(server string) {
-				defer wg.Done()
-				resp, err := d.httpClient.R().Head(fmt.Sprintf("https://%s.gofile.io", server))
-				if err != nil {
-					errCh <- err
-					return
-				}
-				if resp.IsError() {
-					errCh <- resp.Error().(error)
-					return
-				}
-				errCh <- nil
-				avaiableServers <- server
-			}(server.Name)
-		}
-		wg.Wait()
-		close(avaiableServers)
-		close(errCh)
-		for err := range errCh {
-			if err != nil {
-				return nil, err
-			}
-		}
-		servers := []string{}
-		for s := range avaiableServers {
-			servers = append(servers, s)
-		}
-		if len(servers) == 0 {
-			return nil, fmt.Errorf("no server available")
-		}
-		rand.New(rand.NewSource(0)).Shuffle(len(servers), func(i, j int) {
-			servers[i], servers[j] = servers[j], servers[i]
-		})
-		server = servers[0]
-	}
-	req := d.httpClient.R().
-		SetError(entity.Response[entity.UploadedFile]{}).
-		SetResult(entity.Response[entity.UploadedFile]{}).
-		SetFileReader("file", *params.FileName, params.FileReader)
-	if params.FolderId != nil {
-		req.SetFormData(map[string]string{
-			"folderId": *params.FolderId,
-		})
-	}
-	resp, err := req.Post(fmt.Sprintf("https://%s.gofile.io/contents/uploadfile", server))
+func (d Domain) GetServersContext(ctx context.Context) (*entity.Response[entity.Servers], error) {
+	resp, err := d.do(ctx, retryIdempotent, "", func() (*resty.Response, error) {
+		return d.httpClient.R().
+			SetContext(ctx).
+			SetResult(entity.Response[entity.Servers]{}).
+			Get("/servers")
+	})
 	if err != nil {
 		return nil, err
 	}
-	if resp.IsError() {
-		return nil, resp.Error().(*entity.Response[entity.UploadedFile]).Error()
-	}
-	return resp.Result().(*entity.Response[entity.UploadedFile]), nil
+	return resp.Result().(*entity.Response[entity.Servers]), nil
 }
 
 func (d Domain) CreateFolder(parentFolderId string, options ...params.CreateFolderOption) (*entity.Response[entity.CreatedFolder], error) {
+	return d.CreateFolderContext(context.Background(), parentFolderId, options...)
+}
+
+func (d Domain) CreateFolderContext(ctx context.Context, parentFolderId string, options ...params.CreateFolderOption) (*entity.Response[entity.CreatedFolder], error) {
 	params := &params.CreateFolderParams{}
 	for _, option := range options {
 		option(params)
 	}
-	resp, err := d.httpClient.R().
-		SetError(entity.Response[entity.CreatedFolder]{}).
-		SetResult(entity.Response[entity.CreatedFolder]{}).
-		SetBody(params.Body(parentFolderId)).
-		Post("/contents/createFolder")
+	resp, err := d.do(ctx, retryNonIdempotent, parentFolderId, func() (*resty.Response, error) {
+		return d.httpClient.R().
+			SetContext(ctx).
+			SetResult(entity.Response[entity.CreatedFolder]{}).
+			SetBody(params.Body(parentFolderId)).
+			Post("/contents/createFolder")
+	})
 	if err != nil {
 		return nil, err
 	}
-	if resp.IsError() {
-		return nil, resp.Error().(*entity.Response[entity.CreatedFolder]).Error()
-	}
 	return resp.Result().(*entity.Response[entity.CreatedFolder]), nil
 }
 
 func (d Domain) UpdateContent(contentId string, option params.UpdateContentOption) (*entity.EmptyDataResponse, error) {
+	return d.UpdateContentContext(context.Background(), contentId, option)
+}
+
+func (d Domain) UpdateContentContext(ctx context.Context, contentId string, option params.UpdateContentOption) (*entity.EmptyDataResponse, error) {
+	return d.updateContent(ctx, "", contentId, option)
+}
+
+// updateContent is UpdateContentContext with token instead of the token of the client when it is not empty.
+func (d Domain) updateContent(ctx context.Context, token, contentId string, option params.UpdateContentOption) (*entity.EmptyDataResponse, error) {
 	params := &params.UpdateContentParams{}
 	option(params)
 	if params.Attribute == "" {
 		return nil, fmt.Errorf("no attribute provided")
 	}
-	resp, err := d.httpClient.R().
-		SetError(entity.EmptyDataResponse{}).
-		SetResult(entity.EmptyDataResponse{}).
-		SetBody(params.Body()).
-		Put(fmt.Sprintf("/contents/%s/update", contentId))
+	resp, err := d.do(ctx, retryIdempotent, contentId, func() (*resty.Response, error) {
+		req := d.httpClient.R()
+		if token != "" {
+			req.SetAuthToken(token)
+		}
+		return req.
+			SetContext(ctx).
+			SetResult(entity.EmptyDataResponse{}).
+			SetBody(params.Body()).
+			Put(fmt.Sprintf("/contents/%s/update", contentId))
+	})
 	if err != nil {
 		return nil, err
 	}
-	if resp.IsError() {
-		return nil, resp.Error().(*entity.EmptyDataResponse).Error()
-	}
 	return resp.Result().(*entity.EmptyDataResponse), nil
 }
 
 func (d Domain) DeleteContents(contentsId []string) (*entity.Response[map[string]entity.EmptyDataResponse], error) {
-	resp, err := d.httpClient.R().
-		SetError(entity.Response[map[string]entity.EmptyDataResponse]{}).
-		SetResult(entity.Response[map[string]entity.EmptyDataResponse]{}).
-		SetBody(map[string]interface{}{
-			"contentsId": strings.Join(contentsId, ","),
-		}).
-		Delete("/contents")
+	return d.DeleteContentsContext(context.Background(), contentsId)
+}
+
+func (d Domain) DeleteContentsContext(ctx context.Context, contentsId []string) (*entity.Response[map[string]entity.EmptyDataResponse], error) {
+	resp, err := d.do(ctx, retryIdempotent, strings.Join(contentsId, ","), func() (*resty.Response, error) {
+		return d.httpClient.R().
+			SetContext(ctx).
+			SetResult(entity.Response[map[string]entity.EmptyDataResponse]{}).
+			SetBody(map[string]interface{}{
+				"contentsId": strings.Join(contentsId, ","),
+			}).
+			Delete("/contents")
+	})
 	if err != nil {
 		return nil, err
 	}
-	if resp.IsError() {
-		return nil, resp.Error().(*entity.Response[map[string]entity.EmptyDataResponse]).Error()
-	}
 	return resp.Result().(*entity.Response[map[string]entity.EmptyDataResponse]), nil
 }
 
 func (d Domain) DeleteContent(contentId string) (*entity.EmptyDataResponse, error) {
-	resp, err := d.httpClient.R().
-		SetError(entity.EmptyDataResponse{}).
-		SetResult(entity.EmptyDataResponse{}).
-		Delete(fmt.Sprintf("/contents/%s", contentId))
+	return d.DeleteContentContext(context.Background(), contentId)
+}
+
+func (d Domain) DeleteContentContext(ctx context.Context, contentId string) (*entity.EmptyDataResponse, error) {
+	return d.deleteContent(ctx, "", contentId)
+}
+
+// deleteContent is DeleteContentContext with token instead of the token of the client when it is not empty.
+func (d Domain) deleteContent(ctx context.Context, token, contentId string) (*entity.EmptyDataResponse, error) {
+	resp, err := d.do(ctx, retryIdempotent, contentId, func() (*resty.Response, error) {
+		req := d.httpClient.R()
+		if token != "" {
+			req.SetAuthToken(token)
+		}
+		return req.
+			SetContext(ctx).
+			SetResult(entity.EmptyDataResponse{}).
+			Delete(fmt.Sprintf("/contents/%s", contentId))
+	})
 	if err != nil {
 		return nil, err
 	}
-	if resp.IsError() {
-		return nil, resp.Error().(*entity.EmptyDataResponse).Error()
-	}
 	return resp.Result().(*entity.EmptyDataResponse), nil
 }
 
-func (d Domain) GetContent(contentId string) (*entity.Response[entity.Content], error) {
-	resp, err := d.httpClient.R().
-		SetError(entity.Response[interface{}]{}).
-		SetResult(entity.Response[interface{}]{}).
-		Get(fmt.Sprintf("/contents/%s", contentId))
+func (d Domain) GetContent(contentId string, options ...params.GetContentOption) (*entity.Response[entity.Content], error) {
+	return d.GetContentContext(context.Background(), contentId, options...)
+}
+
+func (d Domain) GetContentContext(ctx context.Context, contentId string, options ...params.GetContentOption) (*entity.Response[entity.Content], error) {
+	params := &params.GetContentParams{}
+	for _, option := range options {
+		option(params)
+	}
+	resp, err := d.do(ctx, retryIdempotent, contentId, func() (*resty.Response, error) {
+		return d.httpClient.R().
+			SetContext(ctx).
+			SetResult(entity.Response[interface{}]{}).
+			SetQueryParams(params.Query()).
+			Get(fmt.Sprintf("/contents/%s", contentId))
+	})
 	if err != nil {
 		return nil, err
 	}
-	if resp.IsError() {
-		return nil, resp.Error().(*entity.Response[interface{}]).Error()
-	}
 	result := resp.Result().(*entity.Response[interface{}])
 	content := entity.Content{}
 	if err := content.Unmarshal(result.Data); err != nil {
@@ -211,163 +213,228 @@ func (d Domain) GetContent(contentId string) (*entity.Response[entity.Content],
 }
 
 func (d Domain) CreateDirectLink(contentId string, directLink entity.DirectLink) (*entity.Response[entity.DirectLink], error) {
-	resp, err := d.httpClient.R().
-		SetError(entity.Response[entity.DirectLink]{}).
-		SetResult(entity.Response[entity.DirectLink]{}).
-		SetBody(directLink).
-		Post(fmt.Sprintf("/contents/%s/directlinks", contentId))
+	return d.CreateDirectLinkContext(context.Background(), contentId, directLink)
+}
+
+func (d Domain) CreateDirectLinkContext(ctx context.Context, contentId string, directLink entity.DirectLink) (*entity.Response[entity.DirectLink], error) {
+	resp, err := d.do(ctx, retryNonIdempotent, contentId, func() (*resty.Response, error) {
+		return d.httpClient.R().
+			SetContext(ctx).
+			SetResult(entity.Response[entity.DirectLink]{}).
+			SetBody(directLink).
+			Post(fmt.Sprintf("/contents/%s/directlinks", contentId))
+	})
 	if err != nil {
 		return nil, err
 	}
-	if resp.IsError() {
-		return nil, resp.Error().(*entity.Response[entity.DirectLink]).Error()
-	}
 	return resp.Result().(*entity.Response[entity.DirectLink]), nil
 }
 
 func (d Domain) UpdateDirectLink(contentId, directLinkId string, directLink entity.DirectLink) (*entity.Response[entity.DirectLink], error) {
-	resp, err := d.httpClient.R().
-		SetError(entity.Response[entity.DirectLink]{}).
-		SetResult(entity.Response[entity.DirectLink]{}).
-		SetBody(directLink).
-		Put(fmt.Sprintf("/contents/%s/directlinks/%s", contentId, directLinkId))
+	return d.UpdateDirectLinkContext(context.Background(), contentId, directLinkId, directLink)
+}
+
+func (d Domain) UpdateDirectLinkContext(ctx context.Context, contentId, directLinkId string, directLink entity.DirectLink) (*entity.Response[entity.DirectLink], error) {
+	resp, err := d.do(ctx, retryIdempotent, contentId, func() (*resty.Response, error) {
+		return d.httpClient.R().
+			SetContext(ctx).
+			SetResult(entity.Response[entity.DirectLink]{}).
+			SetBody(directLink).
+			Put(fmt.Sprintf("/contents/%s/directlinks/%s", contentId, directLinkId))
+	})
 	if err != nil {
 		return nil, err
 	}
-	if resp.IsError() {
-		return nil, resp.Error().(*entity.Response[entity.DirectLink]).Error()
-	}
 	return resp.Result().(*entity.Response[entity.DirectLink]), nil
 }
 
 func (d Domain) DeleteDirectLink(contentId, directLinkId string) (*entity.EmptyDataResponse, error) {
-	resp, err := d.httpClient.R().
-		SetError(entity.EmptyDataResponse{}).
-		SetResult(entity.EmptyDataResponse{}).
-		Delete(fmt.Sprintf("/contents/%s/directlinks/%s", contentId, directLinkId))
+	return d.DeleteDirectLinkContext(context.Background(), contentId, directLinkId)
+}
+
+func (d Domain) DeleteDirectLinkContext(ctx context.Context, contentId, directLinkId string) (*entity.EmptyDataResponse, error) {
+	resp, err := d.do(ctx, retryIdempotent, contentId, func() (*resty.Response, error) {
+		return d.httpClient.R().
+			SetContext(ctx).
+			SetResult(entity.EmptyDataResponse{}).
+			Delete(fmt.Sprintf("/contents/%s/directlinks/%s", contentId, directLinkId))
+	})
 	if err != nil {
 		return nil, err
 	}
-	if resp.IsError() {
-		return nil, resp.Error().(*entity.Response[entity.EmptyDataResponse]).Error()
-	}
 	return resp.Result().(*entity.EmptyDataResponse), nil
 }
 
 func (d Domain) CopyContents(folderId string, contentsId []string) (*entity.EmptyDataResponse, error) {
-	resp, err := d.httpClient.R().
-		SetError(entity.EmptyDataResponse{}).
-		SetResult(entity.EmptyDataResponse{}).
-		SetBody(map[string]interface{}{
-			"folderId":   folderId,
-			"contentsId": strings.Join(contentsId, ","),
-		}).
-		Post("/contents/copy")
+	return d.CopyContentsContext(context.Background(), folderId, contentsId)
+}
+
+func (d Domain) CopyContentsContext(ctx context.Context, folderId string, contentsId []string) (*entity.EmptyDataResponse, error) {
+	resp, err := d.do(ctx, retryNonIdempotent, strings.Join(contentsId, ","), func() (*resty.Response, error) {
+		return d.httpClient.R().
+			SetContext(ctx).
+			SetResult(entity.EmptyDataResponse{}).
+			SetBody(map[string]interface{}{
+				"folderId":   folderId,
+				"contentsId": strings.Join(contentsId, ","),
+			}).
+			Post("/contents/copy")
+	})
 	if err != nil {
 		return nil, err
 	}
-	if resp.IsError() {
-		return nil, resp.Error().(*entity.EmptyDataResponse).Error()
-	}
 	return resp.Result().(*entity.EmptyDataResponse), nil
 }
 
 func (d Domain) CopyContent(folderId, contentId string) (*entity.EmptyDataResponse, error) {
-	resp, err := d.httpClient.R().
-		SetError(entity.EmptyDataResponse{}).
-		SetResult(entity.EmptyDataResponse{}).
-		SetBody(map[string]interface{}{
-			"folderId": folderId,
-		}).
-		Post(fmt.Sprintf("/contents/%s/copy", contentId))
+	return d.CopyContentContext(context.Background(), folderId, contentId)
+}
+
+func (d Domain) CopyContentContext(ctx context.Context, folderId, contentId string) (*entity.EmptyDataResponse, error) {
+	resp, err := d.do(ctx, retryNonIdempotent, contentId, func() (*resty.Response, error) {
+		return d.httpClient.R().
+			SetContext(ctx).
+			SetResult(entity.EmptyDataResponse{}).
+			SetBody(map[string]interface{}{
+				"folderId": folderId,
+			}).
+			Post(fmt.Sprintf("/contents/%s/copy", contentId))
+	})
 	if err != nil {
 		return nil, err
 	}
-	if resp.IsError() {
-		return nil, resp.Error().(*entity.EmptyDataResponse).Error()
-	}
 	return resp.Result().(*entity.EmptyDataResponse), nil
 }
 
 func (d Domain) MoveContents(folderId string, contentsId []string) (*entity.EmptyDataResponse, error) {
-	resp, err := d.httpClient.R().
-		SetError(entity.EmptyDataResponse{}).
-		SetResult(entity.EmptyDataResponse{}).
-		SetBody(map[string]interface{}{
-			"folderId":   folderId,
-			"contentsId": strings.Join(contentsId, ","),
-		}).
-		Put("/contents/move")
+	return d.MoveContentsContext(context.Background(), folderId, contentsId)
+}
+
+func (d Domain) MoveContentsContext(ctx context.Context, folderId string, contentsId []string) (*entity.EmptyDataResponse, error) {
+	resp, err := d.do(ctx, retryIdempotent, strings.Join(contentsId, ","), func() (*resty.Response, error) {
+		return d.httpClient.R().
+			SetContext(ctx).
+			SetResult(entity.EmptyDataResponse{}).
+			SetBody(map[string]interface{}{
+				"folderId":   folderId,
+				"contentsId": strings.Join(contentsId, ","),
+			}).
+			Put("/contents/move")
+	})
 	if err != nil {
 		return nil, err
 	}
-	if resp.IsError() {
-		return nil, resp.Error().(*entity.EmptyDataResponse).Error()
-	}
 	return resp.Result().(*entity.EmptyDataResponse), nil
 }
 
 func (d Domain) MoveContent(folderId, contentId string) (*entity.EmptyDataResponse, error) {
-	resp, err := d.httpClient.R().
-		SetError(entity.EmptyDataResponse{}).
-		SetResult(entity.EmptyDataResponse{}).
-		SetBody(map[string]interface{}{
-			"folderId": folderId,
-		}).
-		Put(fmt.Sprintf("/contents/%s/move", contentId))
+	return d.MoveContentContext(context.Background(), folderId, contentId)
+}
+
+func (d Domain) MoveContentContext(ctx context.Context, folderId, contentId string) (*entity.EmptyDataResponse, error) {
+	resp, err := d.do(ctx, retryIdempotent, contentId, func() (*resty.Response, error) {
+		return d.httpClient.R().
+			SetContext(ctx).
+			SetResult(entity.EmptyDataResponse{}).
+			SetBody(map[string]interface{}{
+				"folderId": folderId,
+			}).
+			Put(fmt.Sprintf("/contents/%s/move", contentId))
+	})
 	if err != nil {
 		return nil, err
 	}
-	if resp.IsError() {
-		return nil, resp.Error().(*entity.EmptyDataResponse).Error()
-	}
 	return resp.Result().(*entity.EmptyDataResponse), nil
 }
 
 func (d Domain) GetAccountId() (*entity.Response[entity.GetId], error) {
-	resp, err := d.httpClient.R().
-		SetError(entity.Response[entity.GetId]{}).
-		SetResult(entity.Response[entity.GetId]{}).
-		Get("/accounts/getid")
+	return d.GetAccountIdContext(context.Background())
+}
+
+func (d Domain) GetAccountIdContext(ctx context.Context) (*entity.Response[entity.GetId], error) {
+	resp, err := d.do(ctx, retryIdempotent, "", func() (*resty.Response, error) {
+		return d.httpClient.R().
+			SetContext(ctx).
+			SetResult(entity.Response[entity.GetId]{}).
+			Get("/accounts/getid")
+	})
 	if err != nil {
 		return nil, err
 	}
-	if resp.IsError() {
-		return nil, resp.Error().(*entity.Response[entity.GetId]).Error()
-	}
 	return resp.Result().(*entity.Response[entity.GetId]), nil
 }
 
 func (d Domain) GetAccount(accountId string) (*entity.Response[entity.Account], error) {
-	resp, err := d.httpClient.R().
-		SetError(entity.Response[entity.Account]{}).
-		SetResult(entity.Response[entity.Account]{}).
-		Get(fmt.Sprintf("/accounts/%s", accountId))
+	return d.GetAccountContext(context.Background(), accountId)
+}
+
+func (d Domain) GetAccountContext(ctx context.Context, accountId string) (*entity.Response[entity.Account], error) {
+	resp, err := d.do(ctx, retryIdempotent, "", func() (*resty.Response, error) {
+		return d.httpClient.R().
+			SetContext(ctx).
+			SetResult(entity.Response[entity.Account]{}).
+			Get(fmt.Sprintf("/accounts/%s", accountId))
+	})
 	if err != nil {
 		return nil, err
 	}
-	if resp.IsError() {
-		return nil, resp.Error().(*entity.Response[entity.Account]).Error()
-	}
 	return resp.Result().(*entity.Response[entity.Account]), nil
 }
 
 func (d Domain) ResetAccountToken(accountId string) (*entity.EmptyDataResponse, error) {
-	resp, err := d.httpClient.R().
-		SetError(entity.EmptyDataResponse{}).
-		SetResult(entity.EmptyDataResponse{}).
-		Post(fmt.Sprintf("/accounts/%s/resettoken", accountId))
+	return d.ResetAccountTokenContext(context.Background(), accountId)
+}
+
+func (d Domain) ResetAccountTokenContext(ctx context.Context, accountId string) (*entity.EmptyDataResponse, error) {
+	resp, err := d.do(ctx, retryNonIdempotent, "", func() (*resty.Response, error) {
+		return d.httpClient.R().
+			SetContext(ctx).
+			SetResult(entity.EmptyDataResponse{}).
+			Post(fmt.Sprintf("/accounts/%s/resettoken", accountId))
+	})
 	if err != nil {
 		return nil, err
 	}
-	if resp.IsError() {
-		return nil, resp.Error().(*entity.EmptyDataResponse).Error()
-	}
 	return resp.Result().(*entity.EmptyDataResponse), nil
 }
 
+func (d Domain) CreateAccount(email string) (*entity.Response[entity.CreatedAccount], error) {
+	return d.CreateAccountContext(context.Background(), email)
+}
+
+func (d Domain) CreateAccountContext(ctx context.Context, email string) (*entity.Response[entity.CreatedAccount], error) {
+	resp, err := d.do(ctx, retryNonIdempotent, "", func() (*resty.Response, error) {
+		req := d.httpClient.R().
+			SetContext(ctx).
+			SetResult(entity.Response[entity.CreatedAccount]{})
+		if email != "" {
+			req.SetBody(map[string]interface{}{
+				"email": email,
+			})
+		}
+		return req.Post("/accounts")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Result().(*entity.Response[entity.CreatedAccount]), nil
+}
+
+func newAPIError(resp *resty.Response, contentId string) error {
+	endpoint := resp.Request.URL
+	if resp.Request.RawRequest != nil {
+		endpoint = resp.Request.RawRequest.URL.Path
+	}
+	return entity.NewAPIError(resp.StatusCode(), resp.Request.Method, endpoint, contentId, resp.Body())
+}
+
 func NewAPI() API {
 	return &Domain{
-		httpClient: resty.New().SetBaseURL("https://api.gofile.io"),
+		httpClient:        resty.New().SetBaseURL(DefaultBaseURL).SetPreRequestHook(setContentLength),
+		uploadURLTemplate: DefaultUploadURLTemplate,
+		serverSelector:    selector.Random(),
+		serverState: &serverState{
+			health: map[string]*entity.ServerHealth{},
+		},
 	}
 }
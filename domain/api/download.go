@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/dvwzj/gofile/entity"
+	"github.com/go-resty/resty/v2"
+)
+
+func (d Domain) OpenLink(link string, offset int64) (*http.Response, error) {
+	return d.OpenLinkContext(context.Background(), link, offset)
+}
+
+// OpenLinkContext starts the download of the link of a file (entity.ChildContentFile.Link) from offset,
+// with the token of the client in the accountToken cookie. The caller closes the body of the response,
+// its status is 206 when the server honored the range.
+func (d Domain) OpenLinkContext(ctx context.Context, link string, offset int64) (*http.Response, error) {
+	return d.OpenLinkRangeContext(ctx, link, offset, 0)
+}
+
+func (d Domain) OpenLinkRange(link string, offset, length int64) (*http.Response, error) {
+	return d.OpenLinkRangeContext(context.Background(), link, offset, length)
+}
+
+// OpenLinkRangeContext is OpenLinkContext for the length bytes from offset, a length of 0 reads to the end.
+func (d Domain) OpenLinkRangeContext(ctx context.Context, link string, offset, length int64) (*http.Response, error) {
+	resp, err := d.do(ctx, retryIdempotent, "", func() (*resty.Response, error) {
+		req := d.httpClient.R().
+			SetContext(ctx).
+			SetDoNotParseResponse(true)
+		if d.httpClient.Token != "" {
+			req.SetCookie(&http.Cookie{Name: "accountToken", Value: d.httpClient.Token})
+		}
+		if length > 0 {
+			req.SetHeader("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+		} else if offset > 0 {
+			req.SetHeader("Range", fmt.Sprintf("bytes=%d-", offset))
+		}
+		resp, err := req.Get(link)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode() >= http.StatusBadRequest {
+			// the body is not read by resty, the api error is built from it here
+			body := resp.RawBody()
+			defer body.Close()
+			b, _ := io.ReadAll(io.LimitReader(body, 64*1024))
+			return nil, entity.NewAPIError(resp.StatusCode(), http.MethodGet, resp.RawResponse.Request.URL.Path, "", b)
+		}
+		return resp, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.RawResponse, nil
+}
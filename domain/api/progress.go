@@ -0,0 +1,79 @@
+package api
+
+import (
+	"io"
+	"time"
+
+	"github.com/dvwzj/gofile/params"
+)
+
+// progressReader reports the bytes read from the source of an upload,
+// which the pipe hands over to the request as they are read.
+type progressReader struct {
+	reader     io.Reader
+	progress   params.ProgressFunc
+	interval   time.Duration
+	total      int64
+	sent       int64
+	started    time.Time
+	lastReport time.Time
+	lastSent   int64
+	done       bool
+}
+
+func newProgressReader(reader io.Reader, total *int64, progress params.ProgressFunc, interval time.Duration) *progressReader {
+	if interval <= 0 {
+		interval = params.DefaultProgressInterval
+	}
+	now := time.Now()
+	p := &progressReader{
+		reader:     reader,
+		progress:   progress,
+		interval:   interval,
+		total:      -1,
+		started:    now,
+		lastReport: now,
+	}
+	if total != nil {
+		p.total = *total
+	}
+	return p
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.reader.Read(b)
+	p.sent += int64(n)
+	now := time.Now()
+	done := err == io.EOF || (p.total >= 0 && p.sent >= p.total)
+	if (done && !p.done) || now.Sub(p.lastReport) >= p.interval {
+		p.done = done
+		p.report(now)
+	}
+	return n, err
+}
+
+func (p *progressReader) report(now time.Time) {
+	elapsed := now.Sub(p.started)
+	progress := params.Progress{
+		BytesSent:  p.sent,
+		TotalBytes: p.total,
+		ETA:        -1,
+		Elapsed:    elapsed,
+		Done:       p.done,
+	}
+	if interval := now.Sub(p.lastReport).Seconds(); interval > 0 {
+		progress.Speed = float64(p.sent-p.lastSent) / interval
+	}
+	if elapsed > 0 {
+		progress.AverageSpeed = float64(p.sent) / elapsed.Seconds()
+	}
+	if p.total >= 0 {
+		progress.ETA = 0
+		if remaining := p.total - p.sent; remaining > 0 && progress.AverageSpeed > 0 {
+			progress.ETA = time.Duration(float64(remaining) / progress.AverageSpeed * float64(time.Second))
+		}
+	}
+	p.lastReport = now
+	p.lastSent = p.sent
+	p.progress(progress)
+}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+
 	"github.com/dvwzj/gofile/entity"
 	"github.com/dvwzj/gofile/params"
 )
@@ -9,68 +11,90 @@ type Repository interface {
 	// GET
 	// https://api.gofile.io/servers
 	GetServers() (*entity.Response[entity.Servers], error)
+	GetServersContext(ctx context.Context) (*entity.Response[entity.Servers], error)
 
 	// POST
 	// https://{server}.gofile.io/contents/uploadfile
 	UploadFile(file params.UploadFile, options ...params.UploadFileOption) (*entity.Response[entity.UploadedFile], error)
+	UploadFileContext(ctx context.Context, file params.UploadFile, options ...params.UploadFileOption) (*entity.Response[entity.UploadedFile], error)
 
 	// POST
 	// https://api.gofile.io/contents/createFolder
 	CreateFolder(parentFolderId string, options ...params.CreateFolderOption) (*entity.Response[entity.CreatedFolder], error)
+	CreateFolderContext(ctx context.Context, parentFolderId string, options ...params.CreateFolderOption) (*entity.Response[entity.CreatedFolder], error)
 
 	// PUT
 	// https://api.gofile.io/contents/{contentId}/update
 	UpdateContent(contentId string, option params.UpdateContentOption) (*entity.EmptyDataResponse, error)
+	UpdateContentContext(ctx context.Context, contentId string, option params.UpdateContentOption) (*entity.EmptyDataResponse, error)
 
 	// DELETE
 	// https://api.gofile.io/contents
 	DeleteContents(contentsId []string) (*entity.Response[map[string]entity.EmptyDataResponse], error)
+	DeleteContentsContext(ctx context.Context, contentsId []string) (*entity.Response[map[string]entity.EmptyDataResponse], error)
 
 	// DELETE
 	// https://api.gofile.io/contents/{contentId}
 	DeleteContent(contentId string) (*entity.EmptyDataResponse, error)
+	DeleteContentContext(ctx context.Context, contentId string) (*entity.EmptyDataResponse, error)
 
 	// GET
 	// https://api.gofile.io/contents/{contentId}
-	GetContent(contentId string) (*entity.Response[entity.Content], error)
+	GetContent(contentId string, options ...params.GetContentOption) (*entity.Response[entity.Content], error)
+	GetContentContext(ctx context.Context, contentId string, options ...params.GetContentOption) (*entity.Response[entity.Content], error)
 
 	// POST
 	// https://api.gofile.io/contents/{contentId}/directlinks
 	CreateDirectLink(contentId string, directLink entity.DirectLink) (*entity.Response[entity.DirectLink], error)
+	CreateDirectLinkContext(ctx context.Context, contentId string, directLink entity.DirectLink) (*entity.Response[entity.DirectLink], error)
 
 	// PUT
 	// https://api.gofile.io/contents/{contentId}/directlinks/{directLinkId}
 	UpdateDirectLink(contentId, directLinkId string, directLink entity.DirectLink) (*entity.Response[entity.DirectLink], error)
+	UpdateDirectLinkContext(ctx context.Context, contentId, directLinkId string, directLink entity.DirectLink) (*entity.Response[entity.DirectLink], error)
 
 	// DELETE
 	// https://api.gofile.io/contents/{contentId}/directlinks/{directLinkId}
 	DeleteDirectLink(contentId, directLinkId string) (*entity.EmptyDataResponse, error)
+	DeleteDirectLinkContext(ctx context.Context, contentId, directLinkId string) (*entity.EmptyDataResponse, error)
 
 	// POST
 	// https://api.gofile.io/contents/copy
 	CopyContents(folderId string, contentsId []string) (*entity.EmptyDataResponse, error)
+	CopyContentsContext(ctx context.Context, folderId string, contentsId []string) (*entity.EmptyDataResponse, error)
 
 	// POST
 	// https://api.gofile.io/contents/{contentId}/copy
 	CopyContent(folderId, contentId string) (*entity.EmptyDataResponse, error)
+	CopyContentContext(ctx context.Context, folderId, contentId string) (*entity.EmptyDataResponse, error)
 
 	// PUT
 	// https://api.gofile.io/contents/move
 	MoveContents(folderId string, contentsId []string) (*entity.EmptyDataResponse, error)
+	MoveContentsContext(ctx context.Context, folderId string, contentsId []string) (*entity.EmptyDataResponse, error)
 
 	// PUT
 	// https://api.gofile.io/contents/{contentId}/move
 	MoveContent(folderId string, contentId string) (*entity.EmptyDataResponse, error)
+	MoveContentContext(ctx context.Context, folderId string, contentId string) (*entity.EmptyDataResponse, error)
 
 	// GET
 	// https://api.gofile.io/accounts/getid
 	GetAccountId() (*entity.Response[entity.GetId], error)
+	GetAccountIdContext(ctx context.Context) (*entity.Response[entity.GetId], error)
 
 	// GET
 	// https://api.gofile.io/accounts/{accountId}
 	GetAccount(accountId string) (*entity.Response[entity.Account], error)
+	GetAccountContext(ctx context.Context, accountId string) (*entity.Response[entity.Account], error)
+
+	// POST
+	// https://api.gofile.io/accounts
+	CreateAccount(email string) (*entity.Response[entity.CreatedAccount], error)
+	CreateAccountContext(ctx context.Context, email string) (*entity.Response[entity.CreatedAccount], error)
 
 	// POST
 	// https://api.gofile.io/accounts/{accountId}/resettoken
 	ResetAccountToken(accountId string) (*entity.EmptyDataResponse, error)
+	ResetAccountTokenContext(ctx context.Context, accountId string) (*entity.EmptyDataResponse, error)
 }
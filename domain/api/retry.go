@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/dvwzj/gofile/entity"
+	"github.com/go-resty/resty/v2"
+)
+
+type retryKind int
+
+const (
+	retryNever retryKind = iota
+	// GET, PUT and DELETE give the same result when sent twice.
+	retryIdempotent
+	// POST may have been applied even when the response is lost.
+	retryNonIdempotent
+	// The body of an upload is sent again from a rewound reader.
+	retryUpload
+)
+
+type RetryPolicy struct {
+	// MaxAttempts includes the first attempt, 1 or less disables retries.
+	MaxAttempts int
+	// MaxElapsed stops retrying when the next attempt would start after it, zero means no limit.
+	MaxElapsed     time.Duration
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter spreads each backoff by up to this fraction of it (0.2 = ±20%).
+	Jitter float64
+	// RetryNonIdempotent retries POST requests (copy, createFolder, directlinks, accounts)
+	// on server and network errors, which may apply them twice. Rate limited POSTs are always retried.
+	RetryNonIdempotent bool
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    4,
+	MaxElapsed:     2 * time.Minute,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     30 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+func (d *Domain) SetRetryPolicy(policy RetryPolicy) {
+	d.retryPolicy = &policy
+}
+
+// Backoff returns the wait before the attempt following the given one (starting at 1).
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	initial, max, multiplier := p.InitialBackoff, p.MaxBackoff, p.Multiplier
+	if initial <= 0 {
+		initial = DefaultRetryPolicy.InitialBackoff
+	}
+	if max <= 0 {
+		max = DefaultRetryPolicy.MaxBackoff
+	}
+	if multiplier < 1 {
+		multiplier = DefaultRetryPolicy.Multiplier
+	}
+	backoff := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+	if backoff > float64(max) {
+		backoff = float64(max)
+	}
+	if p.Jitter > 0 {
+		backoff += backoff * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(backoff)
+}
+
+func (p RetryPolicy) retryable(kind retryKind, err error) bool {
+	switch kind {
+	case retryNever:
+		return false
+	case retryNonIdempotent:
+		var apiErr *entity.APIError
+		if errors.As(err, &apiErr) && apiErr.IsRateLimited() {
+			return true
+		}
+		if !p.RetryNonIdempotent {
+			return false
+		}
+	}
+	return entity.IsRetryable(err)
+}
+
+// do sends the request built by send, turns error responses into *entity.APIError
+// and sends it again as long as the retry policy allows it.
+func (d Domain) do(ctx context.Context, kind retryKind, contentId string, send func() (*resty.Response, error)) (*resty.Response, error) {
+	policy := RetryPolicy{MaxAttempts: 1}
+	if d.retryPolicy != nil {
+		policy = *d.retryPolicy
+	}
+	started := time.Now()
+	for attempt := 1; ; attempt++ {
+		resp, err := send()
+		if err == nil && resp.IsError() {
+			err = newAPIError(resp, contentId)
+		}
+		if err == nil {
+			return resp, nil
+		}
+		if attempt >= policy.MaxAttempts || !policy.retryable(kind, err) {
+			return nil, err
+		}
+		backoff := policy.Backoff(attempt)
+		if policy.MaxElapsed > 0 && time.Since(started)+backoff > policy.MaxElapsed {
+			return nil, err
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
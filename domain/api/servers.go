@@ -0,0 +1,208 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dvwzj/gofile/entity"
+	"github.com/dvwzj/gofile/selector"
+)
+
+// serverState is shared by the copies of a Domain, it caches the server
+// list and keeps the health of every server.
+type serverState struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	cooldown  time.Duration
+	servers   []entity.Server
+	fetchedAt time.Time
+	health    map[string]*entity.ServerHealth
+}
+
+func (d *Domain) SetServerSelector(serverSelector selector.ServerSelector) {
+	d.serverSelector = serverSelector
+}
+
+// SetServerCacheTTL keeps the server list and the successful probes for ttl,
+// zero fetches and probes them on every upload.
+func (d *Domain) SetServerCacheTTL(ttl time.Duration) {
+	d.serverState.mu.Lock()
+	defer d.serverState.mu.Unlock()
+	d.serverState.ttl = ttl
+}
+
+// SetServerCooldown skips a server for cooldown after it failed a probe or an upload,
+// zero never skips it.
+func (d *Domain) SetServerCooldown(cooldown time.Duration) {
+	d.serverState.mu.Lock()
+	defer d.serverState.mu.Unlock()
+	d.serverState.cooldown = cooldown
+}
+
+// ServerHealth returns the health of every server seen so far, by name.
+func (d *Domain) ServerHealth() []entity.ServerHealth {
+	d.serverState.mu.Lock()
+	defer d.serverState.mu.Unlock()
+	health := []entity.ServerHealth{}
+	for _, h := range d.serverState.health {
+		health = append(health, *h)
+	}
+	sort.Slice(health, func(i, j int) bool {
+		return health[i].Name < health[j].Name
+	})
+	return health
+}
+
+func (d Domain) SelectServers(serverSelector selector.ServerSelector) ([]selector.Candidate, error) {
+	return d.SelectServersContext(context.Background(), serverSelector)
+}
+
+// SelectServersContext orders the upload servers that are not cooling down with serverSelector,
+// or with the client selector when it is nil. Servers without a fresh successful probe are probed.
+func (d Domain) SelectServersContext(ctx context.Context, serverSelector selector.ServerSelector) ([]selector.Candidate, error) {
+	if serverSelector == nil {
+		serverSelector = d.serverSelector
+	}
+	servers, err := d.serverList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(servers) == 0 {
+		return nil, entity.ErrNoServerAvailable
+	}
+	now := time.Now()
+	candidates := []selector.Candidate{}
+	probes := []entity.Server{}
+	state := d.serverState
+	state.mu.Lock()
+	for _, server := range servers {
+		h := state.health[server.Name]
+		switch {
+		case h.IsCooling(now):
+		case state.ttl > 0 && h.Healthy && now.Sub(h.LastChecked) < state.ttl:
+			candidates = append(candidates, selector.Candidate{
+				Server:  server,
+				Latency: h.LastLatency,
+			})
+		default:
+			probes = append(probes, server)
+		}
+	}
+	state.mu.Unlock()
+	if len(candidates) == 0 && len(probes) == 0 {
+		// every server is cooling down, probing them again beats failing
+		probes = servers
+	}
+	probed, errs := d.probeServers(ctx, probes)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	candidates = append(candidates, probed...)
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w: %w", entity.ErrNoServerAvailable, errors.Join(errs...))
+	}
+	candidates = serverSelector.Select(candidates)
+	if len(candidates) == 0 {
+		return nil, entity.ErrNoServerAvailable
+	}
+	return candidates, nil
+}
+
+func (d Domain) serverList(ctx context.Context) ([]entity.Server, error) {
+	state := d.serverState
+	state.mu.Lock()
+	if state.ttl > 0 && state.servers != nil && time.Since(state.fetchedAt) < state.ttl {
+		servers := state.servers
+		state.mu.Unlock()
+		return servers, nil
+	}
+	state.mu.Unlock()
+	resp, err := d.GetServersContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.servers = resp.Data.Servers
+	state.fetchedAt = time.Now()
+	for _, server := range state.servers {
+		if h, ok := state.health[server.Name]; ok {
+			h.Zone = server.Zone
+			continue
+		}
+		state.health[server.Name] = &entity.ServerHealth{
+			Name: server.Name,
+			Zone: server.Zone,
+		}
+	}
+	return state.servers, nil
+}
+
+// probeServers sends a HEAD request to every server, a server that fails is left out.
+func (d Domain) probeServers(ctx context.Context, servers []entity.Server) ([]selector.Candidate, []error) {
+	mu := sync.Mutex{}
+	candidates := []selector.Candidate{}
+	errs := []error{}
+	wg := sync.WaitGroup{}
+	for _, server := range servers {
+		wg.Add(1)
+		go func(server entity.Server) {
+			defer wg.Done()
+			started := time.Now()
+			resp, err := d.httpClient.R().
+				SetContext(ctx).
+				Head(d.UploadURL(server.Name))
+			if err == nil && resp.IsError() {
+				err = newAPIError(resp, "")
+			}
+			latency := time.Since(started)
+			if ctx.Err() == nil {
+				d.reportServer(server.Name, latency, err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", server.Name, err))
+				return
+			}
+			candidates = append(candidates, selector.Candidate{
+				Server:  server,
+				Latency: latency,
+			})
+		}(server)
+	}
+	wg.Wait()
+	return candidates, errs
+}
+
+// reportServer records the outcome of a probe or an upload, a zero latency keeps the last one.
+func (d Domain) reportServer(name string, latency time.Duration, err error) {
+	state := d.serverState
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	h, ok := state.health[name]
+	if !ok {
+		h = &entity.ServerHealth{Name: name}
+		state.health[name] = h
+	}
+	now := time.Now()
+	h.LastChecked = now
+	if err != nil {
+		h.Healthy = false
+		h.Failures++
+		h.LastError = err.Error()
+		h.CoolingUntil = now.Add(state.cooldown)
+		return
+	}
+	h.Healthy = true
+	h.Failures = 0
+	h.LastError = ""
+	h.CoolingUntil = time.Time{}
+	if latency > 0 {
+		h.LastLatency = latency
+	}
+}
@@ -0,0 +1,373 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dvwzj/gofile/encryption"
+	"github.com/dvwzj/gofile/entity"
+	"github.com/dvwzj/gofile/params"
+	"github.com/go-resty/resty/v2"
+)
+
+type contentLengthKey struct{}
+
+// setContentLength gives a streamed body the length stored in its request context,
+// net/http can not know it from an io.Reader and would send it chunked.
+func setContentLength(_ *resty.Client, req *http.Request) error {
+	if length, ok := req.Context().Value(contentLengthKey{}).(int64); ok && length >= 0 {
+		req.ContentLength = length
+	}
+	return nil
+}
+
+func (d Domain) UploadFile(file params.UploadFile, options ...params.UploadFileOption) (*entity.Response[entity.UploadedFile], error) {
+	return d.UploadFileContext(context.Background(), file, options...)
+}
+
+func (d Domain) UploadFileContext(ctx context.Context, file params.UploadFile, options ...params.UploadFileOption) (*entity.Response[entity.UploadedFile], error) {
+	params := &params.UploadFileParams{}
+	err := file(params)
+	if params.Closer != nil {
+		defer params.Closer.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, option := range options {
+		if err := option(params); err != nil {
+			return nil, err
+		}
+	}
+	// an attribute that can not be set fails before the file is sent
+	if _, err := uploadAttributes(params.Attributes); err != nil {
+		return nil, err
+	}
+	if err := checkSkipIfExists(params); err != nil {
+		return nil, err
+	}
+	if params.EncryptFileName {
+		if params.Encryption == nil {
+			return nil, errors.New("an encrypted file name needs an encryption key")
+		}
+		fileName, err := encryption.EncryptFileName(*params.FileName, params.Encryption)
+		if err != nil {
+			return nil, err
+		}
+		params.FileName = &fileName
+	}
+	u := &upload{params: params}
+	if params.FolderId != nil {
+		u.folderId = *params.FolderId
+	}
+	// the body can only be sent again when the reader can be rewound
+	u.kind = retryNever
+	if seeker, ok := params.FileReader.(io.Seeker); ok {
+		if offset, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			u.kind = retryUpload
+			u.seeker = seeker
+			u.start = offset
+		}
+	}
+	if params.SkipIfExists != 0 {
+		existing, err := d.existingFile(ctx, u)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return d.setAttributes(ctx, existing, params)
+		}
+	}
+	maxServers := d.uploadFailover
+	if params.Failover != nil {
+		maxServers = *params.Failover
+	}
+	if u.kind != retryUpload || maxServers < 1 {
+		maxServers = 1
+	}
+	servers := []string{}
+	if params.Server != nil {
+		servers = append(servers, *params.Server)
+	} else if len(params.ServerNames) > 0 {
+		servers = append(servers, params.ServerNames...)
+	} else {
+		candidates, err := d.SelectServersContext(ctx, params.ServerSelector)
+		if err != nil {
+			return nil, err
+		}
+		for _, candidate := range candidates {
+			servers = append(servers, candidate.Server.Name)
+		}
+	}
+	if len(servers) > maxServers {
+		servers = servers[:maxServers]
+	}
+	attempts := []entity.UploadAttempt{}
+	for _, server := range servers {
+		started := time.Now()
+		resp, err := d.uploadTo(ctx, server, u)
+		attempts = append(attempts, entity.UploadAttempt{
+			Server:   server,
+			Err:      err,
+			Duration: time.Since(started),
+		})
+		if err == nil {
+			resp.Data.Attempts = attempts
+			return d.setAttributes(ctx, resp, params)
+		}
+		// another server would answer the same to an api error that is not transient
+		if !entity.IsRetryable(err) || ctx.Err() != nil {
+			break
+		}
+	}
+	if maxServers == 1 {
+		return nil, attempts[0].Err
+	}
+	return nil, &entity.UploadError{Attempts: attempts}
+}
+
+// upload is the state of an UploadFile call shared by the servers it is sent to.
+type upload struct {
+	params   *params.UploadFileParams
+	folderId string
+	kind     retryKind
+	seeker   io.Seeker
+	start    int64
+}
+
+func (d Domain) uploadTo(ctx context.Context, server string, u *upload) (*entity.Response[entity.UploadedFile], error) {
+	params := u.params
+	var md5Hash, sha256Hash hash.Hash
+	resp, err := d.do(ctx, u.kind, u.folderId, func() (*resty.Response, error) {
+		if u.kind == retryUpload {
+			if _, err := u.seeker.Seek(u.start, io.SeekStart); err != nil {
+				return nil, err
+			}
+		}
+		// the digests of the last attempt are the ones of the data the server got
+		md5Hash, sha256Hash = md5.New(), sha256.New()
+		reader := params.FileReader
+		if params.Progress != nil {
+			reader = newProgressReader(reader, params.FileSize, params.Progress, params.ProgressInterval)
+		}
+		fileSize := params.FileSize
+		if params.Encryption != nil {
+			encrypted, err := encryption.NewEncryptReader(reader, params.Encryption)
+			if err != nil {
+				return nil, err
+			}
+			if fileSize != nil {
+				size := encrypted.Size(*fileSize)
+				fileSize = &size
+			}
+			reader = encrypted
+		}
+		reader = io.TeeReader(reader, io.MultiWriter(md5Hash, sha256Hash))
+		body, contentType, length, done := multipartBody(*params.FileName, u.folderId, reader, fileSize)
+		defer func() {
+			// the source is rewound by the next attempt and the digests are read once the writer is done
+			body.Close()
+			<-done
+		}()
+		req := d.httpClient.R()
+		if params.Token != nil {
+			req.SetAuthToken(*params.Token)
+		}
+		return req.
+			SetContext(context.WithValue(ctx, contentLengthKey{}, length)).
+			SetResult(entity.Response[entity.UploadedFile]{}).
+			SetHeader("Content-Type", contentType).
+			SetBody(body).
+			Post(d.UploadURL(server) + "/contents/uploadfile")
+	})
+	if err != nil {
+		if entity.IsRetryable(err) {
+			d.reportServer(server, 0, err)
+		}
+		return nil, err
+	}
+	d.reportServer(server, 0, nil)
+	result := resp.Result().(*entity.Response[entity.UploadedFile])
+	result.Data.SHA256 = hex.EncodeToString(sha256Hash.Sum(nil))
+	sent := hex.EncodeToString(md5Hash.Sum(nil))
+	if result.Data.MD5 != "" && !strings.EqualFold(result.Data.MD5, sent) {
+		checksumErr := &entity.ChecksumError{
+			FileId:   result.Data.FileId,
+			Server:   server,
+			Expected: sent,
+			Actual:   result.Data.MD5,
+		}
+		if params.DeleteOnMismatch {
+			// like setAttributes, the file is deleted with the token it was uploaded with
+			token := result.Data.GuestToken
+			if params.Token != nil {
+				token = *params.Token
+			}
+			if _, err := d.deleteContent(ctx, token, result.Data.FileId); err != nil {
+				return nil, errors.Join(checksumErr, err)
+			}
+			checksumErr.Deleted = true
+		}
+		return nil, checksumErr
+	}
+	return result, nil
+}
+
+// setAttributes sets the attributes of the upload options on the folder of the uploaded file,
+// with the guest token of the upload when there is one. Without folder given, it is the folder
+// the server created for the upload, which holds only this file, so its attributes are set too.
+func (d Domain) setAttributes(ctx context.Context, resp *entity.Response[entity.UploadedFile], fileParams *params.UploadFileParams) (*entity.Response[entity.UploadedFile], error) {
+	token := resp.Data.GuestToken
+	if fileParams.Token != nil {
+		token = *fileParams.Token
+	}
+	attributes, err := uploadAttributes(fileParams.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	for i, option := range fileParams.Attributes {
+		attribute := attributes[i]
+		if _, err := d.updateContent(ctx, token, resp.Data.ParentFolder, option); err != nil {
+			return nil, &entity.PostUploadError{
+				File:      resp.Data,
+				FolderId:  resp.Data.ParentFolder,
+				Attribute: attribute.Attribute,
+				Err:       err,
+			}
+		}
+	}
+	return resp, nil
+}
+
+// uploadAttributes returns the attributes set by options, an option that sets none is an error.
+func uploadAttributes(options []params.UpdateContentOption) ([]params.UpdateContentParams, error) {
+	attributes := make([]params.UpdateContentParams, len(options))
+	for i, option := range options {
+		if option == nil {
+			return nil, errors.New("upload attribute option is nil")
+		}
+		option(&attributes[i])
+		if attributes[i].Attribute == "" {
+			return nil, errors.New("upload attribute option sets no attribute")
+		}
+	}
+	return attributes, nil
+}
+
+// checkSkipIfExists fails when the existing file can not be looked for: the files are only looked for
+// in a folder, and an encrypted upload never matches them as its md5 and encrypted name change every time.
+func checkSkipIfExists(fileParams *params.UploadFileParams) error {
+	if fileParams.SkipIfExists == 0 {
+		return nil
+	}
+	if fileParams.FolderId == nil {
+		return errors.New("skipping an existing file needs a folder")
+	}
+	if fileParams.SkipIfExists&params.ByMD5 != 0 && fileParams.Encryption != nil {
+		return errors.New("an encrypted file can not be skipped by md5")
+	}
+	if fileParams.SkipIfExists&params.ByName != 0 && fileParams.EncryptFileName {
+		return errors.New("a file with an encrypted name can not be skipped by name")
+	}
+	return nil
+}
+
+// existingFile looks for the file of u in its folder, it returns nil when it is not there.
+func (d Domain) existingFile(ctx context.Context, u *upload) (*entity.Response[entity.UploadedFile], error) {
+	fileParams := u.params
+	localMD5 := ""
+	if fileParams.SkipIfExists&params.ByMD5 != 0 {
+		if u.kind != retryUpload {
+			return nil, errors.New("skipping an existing file by md5 needs a source that can be rewound")
+		}
+		hash := md5.New()
+		if _, err := io.Copy(hash, fileParams.FileReader); err != nil {
+			return nil, err
+		}
+		if _, err := u.seeker.Seek(u.start, io.SeekStart); err != nil {
+			return nil, err
+		}
+		localMD5 = hex.EncodeToString(hash.Sum(nil))
+	}
+	resp, err := d.GetContentContext(ctx, u.folderId)
+	if err != nil {
+		return nil, err
+	}
+	folder := resp.Data
+	for _, file := range folder.Children.Files() {
+		if fileParams.SkipIfExists&params.ByName != 0 && file.Name != *fileParams.FileName {
+			continue
+		}
+		if fileParams.SkipIfExists&params.ByMD5 != 0 {
+			if !strings.EqualFold(file.MD5, localMD5) || (fileParams.FileSize != nil && int64(file.Size) != *fileParams.FileSize) {
+				continue
+			}
+		}
+		return &entity.Response[entity.UploadedFile]{
+			Status: "ok",
+			Data: entity.UploadedFile{
+				Code:         folder.Code,
+				FileId:       file.Id,
+				FileName:     file.Name,
+				MD5:          file.MD5,
+				ParentFolder: folder.Id,
+				Existing:     true,
+			},
+		}, nil
+	}
+	return nil, nil
+}
+
+// SetUploadFailover sends a rewindable upload to up to maxServers servers, in the order of
+// the server selector, until one succeeds. 1 or less sends it to a single server.
+func (d *Domain) SetUploadFailover(maxServers int) {
+	d.uploadFailover = maxServers
+}
+
+// multipartBody streams the upload form through a pipe, so the file is never held in memory.
+// The length is -1 when the file size is unknown, done is closed once file is no longer read.
+func multipartBody(fileName, folderId string, file io.Reader, fileSize *int64) (*io.PipeReader, string, int64, <-chan struct{}) {
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	length := int64(-1)
+	if fileSize != nil {
+		// the form without the file content, with the same boundary, gives the overhead
+		overhead := &bytes.Buffer{}
+		counter := multipart.NewWriter(overhead)
+		counter.SetBoundary(writer.Boundary())
+		writeMultipart(counter, fileName, folderId, &bytes.Reader{})
+		length = int64(overhead.Len()) + *fileSize
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pw.CloseWithError(writeMultipart(writer, fileName, folderId, file))
+	}()
+	return pr, writer.FormDataContentType(), length, done
+}
+
+func writeMultipart(writer *multipart.Writer, fileName, folderId string, file io.Reader) error {
+	if folderId != "" {
+		if err := writer.WriteField("folderId", folderId); err != nil {
+			return err
+		}
+	}
+	part, err := writer.CreateFormFile("file", fileName)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return err
+	}
+	return writer.Close()
+}
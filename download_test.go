@@ -0,0 +1,354 @@
+package gofile_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dvwzj/gofile"
+	"github.com/dvwzj/gofile/encryption"
+	"github.com/dvwzj/gofile/entity"
+	"github.com/dvwzj/gofile/gofiletest"
+	"github.com/dvwzj/gofile/params"
+)
+
+func TestDownloadFile(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	account := server.NewAccount(entity.AccountTierPremium)
+	client, err := server.NewClient(gofile.WithToken(account.Token))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data := make([]byte, 256*1024)
+	rand.Read(data)
+	uploaded, err := client.UploadFile(params.WithBytes(data, "data.bin"), params.WithFolderId(account.RootFolder))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	buf := &bytes.Buffer{}
+	file, err := client.DownloadFile(params.WithFileId(uploaded.FileId), buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) || file.Name != "data.bin" || file.MD5 != uploaded.MD5 {
+		t.Fatalf("unexpected download: %+v", file)
+	}
+
+	// the connection is dropped twice, the download resumes from the bytes received
+	link, _ := url.Parse(file.Link)
+	server.CutNext(http.MethodGet, link.Path, 100*1024, 2)
+	buf.Reset()
+	if _, err := client.DownloadFile(params.WithChildFile(*file), buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) || server.Requests(http.MethodGet, link.Path) != 4 {
+		t.Fatalf("unexpected download: %d requests", server.Requests(http.MethodGet, link.Path))
+	}
+
+	server.CutNext(http.MethodGet, link.Path, 100*1024, 2)
+	_, err = client.DownloadFile(params.WithChildFile(*file), &bytes.Buffer{}, params.WithResumeAttempts(1))
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	corrupt := *file
+	corrupt.MD5 = "00000000000000000000000000000000"
+	var checksumErr *entity.ChecksumError
+	if _, err := client.DownloadFile(params.WithChildFile(corrupt), &bytes.Buffer{}); !errors.As(err, &checksumErr) || checksumErr.Actual != uploaded.MD5 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	folder, err := client.CreateFolder(account.RootFolder)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.DownloadFile(params.WithFileId(folder.FolderId), &bytes.Buffer{}); !errors.Is(err, entity.ErrorType) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDownloadToPath(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	account := server.NewAccount(entity.AccountTierPremium)
+	client, err := server.NewClient(gofile.WithToken(account.Token))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data := make([]byte, 64*1024)
+	rand.Read(data)
+	uploaded, err := client.UploadFile(params.WithBytes(data, "data.bin"), params.WithFolderId(account.RootFolder))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	dir := t.TempDir()
+
+	// a previous download stopped after 1000 bytes
+	path := filepath.Join(dir, "data.bin")
+	if err := os.WriteFile(path+".part", data[:1000], 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	file, err := client.DownloadToPath(params.WithFileId(uploaded.FileId), dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	written, err := os.ReadFile(path)
+	if err != nil || !bytes.Equal(written, data) {
+		t.Fatalf("unexpected file: %v", err)
+	}
+	if _, err := os.Stat(path + ".part"); !os.IsNotExist(err) {
+		t.Fatalf("part file was not removed: %v", err)
+	}
+	if _, err := os.Stat(path + ".segments"); !os.IsNotExist(err) {
+		t.Fatalf("segments file was not removed: %v", err)
+	}
+
+	// the files left by a crash during the segments start over
+	path = filepath.Join(dir, "crashed.bin")
+	if err := os.WriteFile(path+".part", nil, 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(path+".segments", make([]byte, len(data)), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.DownloadToPath(params.WithChildFile(*file), path, params.WithSegments(4)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if written, err := os.ReadFile(path); err != nil || !bytes.Equal(written, data) {
+		t.Fatalf("unexpected file: %v", err)
+	}
+	if _, err := os.Stat(path + ".segments"); !os.IsNotExist(err) {
+		t.Fatalf("segments file was not removed: %v", err)
+	}
+
+	// the download link redirects to another host, the account token is sent again
+	redirects := 0
+	redirector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirects++
+		http.Redirect(w, r, file.Link, http.StatusFound)
+	}))
+	defer redirector.Close()
+	redirected := *file
+	redirected.Link = redirector.URL + "/" + file.Id
+	path = filepath.Join(dir, "redirected.bin")
+	if _, err := client.DownloadToPath(params.WithChildFile(redirected), path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if written, err := os.ReadFile(path); err != nil || !bytes.Equal(written, data) || redirects != 1 {
+		t.Fatalf("unexpected file: %v", err)
+	}
+
+	corrupt := *file
+	corrupt.MD5 = "00000000000000000000000000000000"
+	path = filepath.Join(dir, "corrupt.bin")
+	if _, err := client.DownloadToPath(params.WithChildFile(corrupt), path); !errors.Is(err, entity.ErrChecksumMismatch) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(path + ".part"); !os.IsNotExist(err) {
+		t.Fatalf("part file was not removed: %v", err)
+	}
+}
+
+func TestDownloadDecryption(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	account := server.NewAccount(entity.AccountTierPremium)
+	client, err := server.NewClient(gofile.WithToken(account.Token))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	keys := encryption.StaticKey(bytes.Repeat([]byte{7}, 32))
+	data := bytes.Repeat([]byte("secret "), 30000)
+	uploaded, err := client.UploadFile(params.WithBytes(data, "secret.txt"), params.WithFolderId(account.RootFolder), params.WithEncryption(keys), params.WithEncryptedFileName())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	buf := &bytes.Buffer{}
+	file, err := client.DownloadFile(params.WithFileId(uploaded.FileId), buf, params.WithDecryption(keys))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) || file.Name != "secret.txt" {
+		t.Fatalf("unexpected download: %+v", file)
+	}
+
+	dir := t.TempDir()
+	if _, err := client.DownloadToPath(params.WithFileId(uploaded.FileId), dir, params.WithDecryption(keys)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if written, err := os.ReadFile(filepath.Join(dir, "secret.txt")); err != nil || !bytes.Equal(written, data) {
+		t.Fatalf("unexpected file: %v", err)
+	}
+}
+
+func TestDownloadFolder(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	account := server.NewAccount(entity.AccountTierPremium)
+	client, err := server.NewClient(gofile.WithToken(account.Token))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	files := map[string]string{
+		"index.html":         "index",
+		"assets/app.js":      "app",
+		"assets/css/app.css": "css",
+		"empty/.keep":        "",
+	}
+	src := filepath.Join(t.TempDir(), "build")
+	writeTree(t, src, files)
+	uploaded, err := client.UploadDir(src, account.RootFolder)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "mirror")
+	downloaded, err := client.DownloadFolder(uploaded.FolderId, dst, params.WithDownloadWorkers(2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for name, data := range files {
+		path := filepath.Join(dst, filepath.FromSlash(name))
+		written, err := os.ReadFile(path)
+		if err != nil || string(written) != data {
+			t.Fatalf("unexpected file %s: %q, %v", name, written, err)
+		}
+	}
+	folder, err := client.GetContent(uploaded.FolderId)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, file := range folder.Children.Files() {
+		info, err := os.Stat(filepath.Join(dst, file.Name))
+		if err != nil || info.ModTime().Unix() != int64(file.CreateTime) {
+			t.Fatalf("unexpected modification time of %s: %v", file.Name, err)
+		}
+	}
+	if len(downloaded.Files) != 1 || len(downloaded.Folders) != 2 || downloaded.Folders[0].Folders[0].LocalPath != filepath.Join(dst, "assets", "css") {
+		t.Fatalf("unexpected report: %+v", downloaded)
+	}
+
+	// the files already downloaded are skipped, a modified one is downloaded again
+	if err := os.WriteFile(filepath.Join(dst, "index.html"), []byte("INDEX"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	downloaded, err = client.DownloadFolder(uploaded.FolderId, dst)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	skipped := map[string]bool{}
+	downloaded.Walk(func(dir *entity.DownloadedDir) {
+		for _, file := range dir.Files {
+			skipped[file.LocalPath] = file.Skipped
+		}
+	})
+	if len(skipped) != 4 || skipped[filepath.Join(dst, "index.html")] || !skipped[filepath.Join(dst, "assets", "app.js")] {
+		t.Fatalf("unexpected report: %v", skipped)
+	}
+	if written, _ := os.ReadFile(filepath.Join(dst, "index.html")); string(written) != "index" {
+		t.Fatalf("unexpected file: %q", written)
+	}
+
+	if _, err := client.DownloadFolder(downloaded.Files[0].FileId, t.TempDir()); !errors.Is(err, entity.ErrorType) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a folder may hold two files with the same name, the second one is not written over the first
+	same, err := client.CreateFolder(account.RootFolder, params.WithFolderName("same"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sameFiles := []*entity.UploadedFile{}
+	for _, data := range []string{"first", "second"} {
+		uploadedFile, err := client.UploadFile(params.WithBytes([]byte(data), "same.txt"), params.WithFolderId(same.FolderId))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		sameFiles = append(sameFiles, uploadedFile)
+	}
+	// the first one by id is written
+	expected := "first"
+	if sameFiles[1].FileId < sameFiles[0].FileId {
+		expected = "second"
+	}
+	dst = t.TempDir()
+	downloaded, err = client.DownloadFolder(same.FolderId, dst, params.WithDownloadWorkers(2))
+	if err == nil || len(downloaded.Files) != 2 || downloaded.Files[0].Err != nil || downloaded.Files[1].Err == nil {
+		t.Fatalf("unexpected report: %+v, %v", downloaded, err)
+	}
+	if written, _ := os.ReadFile(filepath.Join(dst, "same.txt")); string(written) != expected {
+		t.Fatalf("unexpected file: %q", written)
+	}
+}
+
+func TestDownloadSegments(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	account := server.NewAccount(entity.AccountTierPremium)
+	client, err := server.NewClient(gofile.WithToken(account.Token))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data := make([]byte, 1024*1024+7)
+	rand.Read(data)
+	uploaded, err := client.UploadFile(params.WithBytes(data, "data.bin"), params.WithFolderId(account.RootFolder))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	dir := t.TempDir()
+
+	file, err := client.DownloadToPath(params.WithFileId(uploaded.FileId), dir, params.WithSegments(4))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	link, _ := url.Parse(file.Link)
+	if written, err := os.ReadFile(filepath.Join(dir, "data.bin")); err != nil || !bytes.Equal(written, data) || server.Requests(http.MethodGet, link.Path) != 4 {
+		t.Fatalf("unexpected file: %v", err)
+	}
+
+	// a segment is interrupted and resumed on its own
+	server.CutNext(http.MethodGet, link.Path, 1000, 1)
+	path := filepath.Join(dir, "resumed.bin")
+	if _, err := client.DownloadToPath(params.WithChildFile(*file), path, params.WithSegments(4)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if written, err := os.ReadFile(path); err != nil || !bytes.Equal(written, data) || server.Requests(http.MethodGet, link.Path) != 9 {
+		t.Fatalf("unexpected file: %v", err)
+	}
+
+	// a segment that can not be resumed fails the download and the part file is removed
+	server.CutNext(http.MethodGet, link.Path, 1000, 1)
+	path = filepath.Join(dir, "failed.bin")
+	if _, err := client.DownloadToPath(params.WithChildFile(*file), path, params.WithSegments(4), params.WithResumeAttempts(0)); err == nil {
+		t.Fatalf("expected an error")
+	}
+	if _, err := os.Stat(path + ".part"); !os.IsNotExist(err) {
+		t.Fatalf("part file was not removed: %v", err)
+	}
+
+	// the server ignores the ranges, the file is downloaded with a single stream
+	ranges := []string{}
+	noRanges := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Write(data)
+	}))
+	defer noRanges.Close()
+	single := *file
+	single.Link = noRanges.URL + "/" + file.Id
+	path = filepath.Join(dir, "single.bin")
+	if _, err := client.DownloadToPath(params.WithChildFile(single), path, params.WithSegments(4)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if written, err := os.ReadFile(path); err != nil || !bytes.Equal(written, data) || len(ranges) != 2 || ranges[1] != "" {
+		t.Fatalf("unexpected file: %v, %q", err, ranges)
+	}
+}
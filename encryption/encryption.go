@@ -0,0 +1,362 @@
+// Package encryption encrypts uploads with AES-GCM before they leave the client.
+//
+// The data is cut in chunks sealed one by one, so a file of any size is encrypted and
+// decrypted as a stream. An encrypted file starts with a header:
+//
+//	magic "GOFE" | version (1 byte) | chunk size (uint32) | key id length (1 byte) | key id |
+//	salt (32 bytes) | nonce prefix (7 bytes)
+//
+// The chunks are sealed with a key of the file, derived from the key of the key id and the random
+// salt with HKDF-SHA256, so the nonces of two files never share a key. The header is followed by
+// the chunks, each one the ciphertext of chunk size bytes of data and its 16 bytes tag, except the
+// last one that is shorter (possibly empty). The nonce of a chunk is the nonce prefix,
+// the index of the chunk (uint32) and a byte set to 1 for the last chunk, and the header is the
+// additional data of every chunk, so a chunk can not be reordered, dropped, or moved to another file.
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// DefaultChunkSize is the size of the data sealed in a chunk.
+	DefaultChunkSize = 64 * 1024
+
+	magic           = "GOFE"
+	version         = 2
+	saltSize        = 32
+	noncePrefixSize = 7
+	tagSize         = 16
+	// fileNamePrefix starts an encrypted file name
+	fileNamePrefix = "gofe-"
+)
+
+var (
+	ErrNotEncrypted = errors.New("encryption: not encrypted")
+	// ErrAuthentication is returned when the data was modified, truncated, or the key is wrong.
+	ErrAuthentication = errors.New("encryption: message authentication failed")
+	ErrUnknownKey     = errors.New("encryption: unknown key")
+)
+
+// KeyProvider gives the AES keys (16, 24 or 32 bytes) by id, the id is stored in clear
+// in the header of the encrypted files to find their key back.
+type KeyProvider interface {
+	// EncryptionKey returns the key new files are encrypted with
+	EncryptionKey() (keyId string, key []byte, err error)
+	// DecryptionKey returns the key of keyId, or ErrUnknownKey
+	DecryptionKey(keyId string) ([]byte, error)
+}
+
+type staticKey struct {
+	keyId string
+	key   []byte
+}
+
+// StaticKey encrypts and decrypts with key, its id is derived from its sha256.
+func StaticKey(key []byte) KeyProvider {
+	sum := sha256.Sum256(key)
+	return staticKey{
+		keyId: hex.EncodeToString(sum[:8]),
+		key:   key,
+	}
+}
+
+func (k staticKey) EncryptionKey() (string, []byte, error) {
+	return k.keyId, k.key, nil
+}
+
+func (k staticKey) DecryptionKey(keyId string) ([]byte, error) {
+	if keyId != k.keyId {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyId)
+	}
+	return k.key, nil
+}
+
+type keyring struct {
+	current string
+	keys    map[string][]byte
+}
+
+// Keyring encrypts with the key current of keys and decrypts with any of them,
+// which allows to rotate the keys.
+func Keyring(current string, keys map[string][]byte) KeyProvider {
+	return keyring{
+		current: current,
+		keys:    keys,
+	}
+}
+
+func (k keyring) EncryptionKey() (string, []byte, error) {
+	key, ok := k.keys[k.current]
+	if !ok {
+		return "", nil, fmt.Errorf("%w: %s", ErrUnknownKey, k.current)
+	}
+	return k.current, key, nil
+}
+
+func (k keyring) DecryptionKey(keyId string) ([]byte, error) {
+	key, ok := k.keys[keyId]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyId)
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// fileKey derives the key of a file from key and its salt with HKDF-SHA256 (RFC 5869),
+// the key of the file has the size of key.
+func fileKey(key, salt []byte) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(key)
+	expand := hmac.New(sha256.New, extract.Sum(nil))
+	expand.Write([]byte("gofile encryption file key"))
+	expand.Write([]byte{1})
+	return expand.Sum(nil)[:len(key)]
+}
+
+func chunkNonce(prefix []byte, index uint32, last bool) []byte {
+	nonce := make([]byte, 0, noncePrefixSize+5)
+	nonce = append(nonce, prefix...)
+	nonce = binary.BigEndian.AppendUint32(nonce, index)
+	if last {
+		return append(nonce, 1)
+	}
+	return append(nonce, 0)
+}
+
+// EncryptReader encrypts the data read from its source.
+type EncryptReader struct {
+	src       io.Reader
+	aead      cipher.AEAD
+	header    []byte
+	prefix    []byte
+	chunkSize int
+	index     uint32
+	buf       []byte
+	out       bytes.Buffer
+	done      bool
+}
+
+// NewEncryptReader encrypts src with the encryption key of keys.
+func NewEncryptReader(src io.Reader, keys KeyProvider) (*EncryptReader, error) {
+	keyId, key, err := keys.EncryptionKey()
+	if err != nil {
+		return nil, err
+	}
+	if len(keyId) > 255 {
+		return nil, errors.New("encryption: key id is longer than 255 bytes")
+	}
+	if _, err := aes.NewCipher(key); err != nil {
+		return nil, err
+	}
+	random := make([]byte, saltSize+noncePrefixSize)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	salt, prefix := random[:saltSize], random[saltSize:]
+	aead, err := newAEAD(fileKey(key, salt))
+	if err != nil {
+		return nil, err
+	}
+	header := []byte(magic)
+	header = append(header, version)
+	header = binary.BigEndian.AppendUint32(header, DefaultChunkSize)
+	header = append(header, byte(len(keyId)))
+	header = append(header, keyId...)
+	header = append(header, salt...)
+	header = append(header, prefix...)
+	r := &EncryptReader{
+		src:       src,
+		aead:      aead,
+		header:    header,
+		prefix:    prefix,
+		chunkSize: DefaultChunkSize,
+		buf:       make([]byte, DefaultChunkSize),
+	}
+	r.out.Write(header)
+	return r, nil
+}
+
+// Size returns the size of the encryption of size bytes.
+func (r *EncryptReader) Size(size int64) int64 {
+	chunks := size/int64(r.chunkSize) + 1
+	return int64(len(r.header)) + size + chunks*tagSize
+}
+
+func (r *EncryptReader) Read(p []byte) (int, error) {
+	for r.out.Len() == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(r.src, r.buf)
+		last := false
+		switch {
+		case err == io.EOF || err == io.ErrUnexpectedEOF:
+			last = true
+		case err != nil:
+			return 0, err
+		}
+		r.out.Write(r.aead.Seal(nil, chunkNonce(r.prefix, r.index, last), r.buf[:n], r.header))
+		r.index++
+		r.done = last
+	}
+	return r.out.Read(p)
+}
+
+// DecryptReader decrypts and authenticates the data read from its source,
+// a read fails with ErrAuthentication as soon as a chunk does not authenticate.
+type DecryptReader struct {
+	src    io.Reader
+	aead   cipher.AEAD
+	header []byte
+	prefix []byte
+	index  uint32
+	buf    []byte
+	out    bytes.Buffer
+	done   bool
+}
+
+// NewDecryptReader reads the header of src and finds its key in keys.
+func NewDecryptReader(src io.Reader, keys KeyProvider) (*DecryptReader, error) {
+	fixed := make([]byte, len(magic)+1+4+1)
+	if _, err := io.ReadFull(src, fixed); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrNotEncrypted
+		}
+		return nil, err
+	}
+	if string(fixed[:len(magic)]) != magic {
+		return nil, ErrNotEncrypted
+	}
+	if fileVersion := fixed[len(magic)]; fileVersion != version {
+		return nil, fmt.Errorf("encryption: unknown version %d", fileVersion)
+	}
+	chunkSize := binary.BigEndian.Uint32(fixed[len(magic)+1:])
+	if chunkSize == 0 || chunkSize > 16*1024*1024 {
+		return nil, fmt.Errorf("%w: chunk size %d", ErrAuthentication, chunkSize)
+	}
+	keyIdSize := int(fixed[len(fixed)-1])
+	rest := make([]byte, keyIdSize+saltSize+noncePrefixSize)
+	if _, err := io.ReadFull(src, rest); err != nil {
+		return nil, ErrAuthentication
+	}
+	keyId := string(rest[:keyIdSize])
+	key, err := keys.DecryptionKey(keyId)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := aes.NewCipher(key); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(fileKey(key, rest[keyIdSize:keyIdSize+saltSize]))
+	if err != nil {
+		return nil, err
+	}
+	return &DecryptReader{
+		src:    src,
+		aead:   aead,
+		header: append(fixed, rest...),
+		prefix: rest[len(rest)-noncePrefixSize:],
+		buf:    make([]byte, int(chunkSize)+tagSize),
+	}, nil
+}
+
+func (r *DecryptReader) Read(p []byte) (int, error) {
+	for r.out.Len() == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(r.src, r.buf)
+		// only the last chunk is shorter than a full one
+		last := false
+		switch {
+		case err == io.EOF:
+			return 0, ErrAuthentication
+		case err == io.ErrUnexpectedEOF:
+			last = true
+		case err != nil:
+			return 0, err
+		}
+		plain, err := r.aead.Open(nil, chunkNonce(r.prefix, r.index, last), r.buf[:n], r.header)
+		if err != nil {
+			return 0, ErrAuthentication
+		}
+		r.out.Write(plain)
+		r.index++
+		r.done = last
+	}
+	return r.out.Read(p)
+}
+
+// EncryptFileName encrypts name with the encryption key of keys, the result
+// is a valid file name that starts with "gofe-".
+func EncryptFileName(name string, keys KeyProvider) (string, error) {
+	keyId, key, err := keys.EncryptionKey()
+	if err != nil {
+		return "", err
+	}
+	if len(keyId) > 255 {
+		return "", errors.New("encryption: key id is longer than 255 bytes")
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	data := append([]byte{byte(len(keyId))}, keyId...)
+	data = append(data, nonce...)
+	data = aead.Seal(data, nonce, []byte(name), []byte(keyId))
+	return fileNamePrefix + base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecryptFileName decrypts a name encrypted by EncryptFileName, ErrNotEncrypted
+// is returned for the other names.
+func DecryptFileName(name string, keys KeyProvider) (string, error) {
+	if !strings.HasPrefix(name, fileNamePrefix) {
+		return "", ErrNotEncrypted
+	}
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(name, fileNamePrefix))
+	if err != nil || len(data) < 1 || len(data) < 1+int(data[0]) {
+		return "", ErrNotEncrypted
+	}
+	keyId := string(data[1 : 1+int(data[0])])
+	data = data[1+int(data[0]):]
+	key, err := keys.DecryptionKey(keyId)
+	if err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < aead.NonceSize() {
+		return "", ErrAuthentication
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(keyId))
+	if err != nil {
+		return "", ErrAuthentication
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,133 @@
+package encryption_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/dvwzj/gofile/encryption"
+)
+
+func encrypt(t *testing.T, data []byte, keys encryption.KeyProvider) []byte {
+	t.Helper()
+	r, err := encryption.NewEncryptReader(bytes.NewReader(data), keys)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	encrypted, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if size := r.Size(int64(len(data))); size != int64(len(encrypted)) {
+		t.Fatalf("unexpected size: %d, encrypted %d", size, len(encrypted))
+	}
+	return encrypted
+}
+
+func decrypt(data []byte, keys encryption.KeyProvider) ([]byte, error) {
+	r, err := encryption.NewDecryptReader(bytes.NewReader(data), keys)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestEncryptReader(t *testing.T) {
+	keys := encryption.StaticKey(bytes.Repeat([]byte{1}, 32))
+	for _, size := range []int{0, 1, encryption.DefaultChunkSize - 1, encryption.DefaultChunkSize, 3*encryption.DefaultChunkSize + 7} {
+		data := make([]byte, size)
+		rand.Read(data)
+		encrypted := encrypt(t, data, keys)
+		if size > 16 && bytes.Contains(encrypted, data[:16]) {
+			t.Fatalf("data is not encrypted")
+		}
+		decrypted, err := decrypt(encrypted, keys)
+		if err != nil {
+			t.Fatalf("unexpected error for %d bytes: %v", size, err)
+		}
+		if !bytes.Equal(decrypted, data) {
+			t.Fatalf("unexpected data for %d bytes", size)
+		}
+	}
+}
+
+func TestDecryptReader(t *testing.T) {
+	keys := encryption.StaticKey(bytes.Repeat([]byte{1}, 32))
+	data := make([]byte, 2*encryption.DefaultChunkSize+10)
+	rand.Read(data)
+	encrypted := encrypt(t, data, keys)
+
+	tampered := append([]byte{}, encrypted...)
+	tampered[len(tampered)/2] ^= 1
+	if _, err := decrypt(tampered, keys); !errors.Is(err, encryption.ErrAuthentication) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the last chunk is dropped
+	truncated := encrypted[:len(encrypted)-(10+16)]
+	if _, err := decrypt(truncated, keys); !errors.Is(err, encryption.ErrAuthentication) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := decrypt(encrypted, encryption.StaticKey(bytes.Repeat([]byte{2}, 32))); !errors.Is(err, encryption.ErrUnknownKey) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := decrypt(data, keys); !errors.Is(err, encryption.ErrNotEncrypted) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	keyring := encryption.Keyring("new", map[string][]byte{
+		"old": bytes.Repeat([]byte{3}, 16),
+		"new": bytes.Repeat([]byte{4}, 16),
+	})
+	old := encrypt(t, data, encryption.Keyring("old", map[string][]byte{"old": bytes.Repeat([]byte{3}, 16)}))
+	if decrypted, err := decrypt(old, keyring); err != nil || !bytes.Equal(decrypted, data) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestEncryptVersion(t *testing.T) {
+	key := bytes.Repeat([]byte{5}, 32)
+	keys := encryption.Keyring("k", map[string][]byte{"k": key})
+	data := []byte("the same data")
+	first, second := encrypt(t, data, keys), encrypt(t, data, keys)
+	// magic, version, chunk size, key id length and key id
+	saltStart := 4 + 1 + 4 + 1 + 1
+	if first[4] != 2 || bytes.Equal(first[saltStart:saltStart+32], second[saltStart:saltStart+32]) {
+		t.Fatalf("unexpected headers: %x, %x", first[:saltStart+32], second[:saltStart+32])
+	}
+	// the chunks are not sealed with the key itself
+	block, _ := aes.NewCipher(key)
+	aead, _ := cipher.NewGCM(block)
+	header := first[:saltStart+32+7]
+	nonce := binary.BigEndian.AppendUint32(append([]byte{}, header[len(header)-7:]...), 0)
+	if _, err := aead.Open(nil, append(nonce, 1), first[len(header):], header); err == nil {
+		t.Fatalf("the file is sealed with the key")
+	}
+
+	unknown := append([]byte{}, first...)
+	unknown[4] = 1
+	if _, err := decrypt(unknown, keys); err == nil {
+		t.Fatalf("unexpected nil error")
+	}
+}
+
+func TestEncryptFileName(t *testing.T) {
+	keys := encryption.StaticKey(bytes.Repeat([]byte{1}, 16))
+	name, err := encryption.EncryptFileName("customers.csv", keys)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bytes.ContainsAny([]byte(name), "/\\. ") {
+		t.Fatalf("unexpected file name: %s", name)
+	}
+	if decrypted, err := encryption.DecryptFileName(name, keys); err != nil || decrypted != "customers.csv" {
+		t.Fatalf("unexpected file name: %s, %v", decrypted, err)
+	}
+	if _, err := encryption.DecryptFileName("customers.csv", keys); !errors.Is(err, encryption.ErrNotEncrypted) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
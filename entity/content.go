@@ -1,6 +1,10 @@
 package entity
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
 
 const (
 	ContentTypeFolder ContentType = "folder"
@@ -34,6 +38,15 @@ type UploadedFile struct {
 	FileName     string `json:"fileName"`
 	MD5          string `json:"md5"`
 	ParentFolder string `json:"parentFolder"`
+	// GuestToken is the token of the guest account created by an upload without token nor folder
+	GuestToken string `json:"guestToken,omitempty"`
+	// Existing is true when the file was already in the folder and was not uploaded,
+	// see params.WithSkipIfExists
+	Existing bool `json:"-"`
+	// SHA256 is the hex digest of the data sent, computed while streaming
+	SHA256 string `json:"-"`
+	// Attempts lists the servers the file was sent to, the last one succeeded
+	Attempts []UploadAttempt `json:"-"`
 }
 
 type Content struct {
@@ -50,6 +63,10 @@ type Content struct {
 	Children           *ChildContent `json:"children,omitempty"`
 	IsOwner            *bool         `json:"isOwner,omitempty"`
 	IsRoot             *bool         `json:"isRoot,omitempty"`
+	// Password is true for a content protected by a password, PasswordStatus then tells whether
+	// the password given with params.WithContentPassword is "passwordOk"
+	Password       bool   `json:"password,omitempty"`
+	PasswordStatus string `json:"passwordStatus,omitempty"`
 }
 
 func (c *Content) Unmarshal(v interface{}) error {
@@ -179,6 +196,7 @@ func (c *ChildContent) Files() []ChildContentFile {
 			if v.DirectLinks != nil {
 				content.DirectLinks = v.DirectLinks
 			}
+			contents = append(contents, content)
 		}
 	}
 	return contents
@@ -210,6 +228,8 @@ type UniversalContent struct {
 	FileName           *string                `json:"fileName,omitempty"`
 	FolderId           *string                `json:"folderId,omitempty"`
 	DownloadPage       *string                `json:"downloadPage,omitempty"`
+	Password           *bool                  `json:"password,omitempty"`
+	PasswordStatus     *string                `json:"passwordStatus,omitempty"`
 }
 
 func (c *UniversalContent) Content() Content {
@@ -256,6 +276,12 @@ func (c *UniversalContent) Content() Content {
 	if c.IsRoot != nil {
 		content.IsRoot = c.IsRoot
 	}
+	if c.Password != nil {
+		content.Password = *c.Password
+	}
+	if c.PasswordStatus != nil {
+		content.PasswordStatus = *c.PasswordStatus
+	}
 	return content
 }
 
@@ -310,3 +336,119 @@ func (c *UniversalContent) UploadedFile() UploadedFile {
 	}
 	return content
 }
+
+// UploadedDir is the result of UploadDir for a local directory and the folder created for it.
+type UploadedDir struct {
+	LocalPath string
+	FolderId  string
+	Code      string
+	// Err is set when the folder could not be created or the directory could not be read,
+	// its content is then not uploaded
+	Err     error
+	Files   []UploadedDirFile
+	Folders []*UploadedDir
+	// Resumed is true when the folder was created by a previous run, see params.WithJournal
+	Resumed bool
+}
+
+type UploadedDirFile struct {
+	LocalPath string
+	FileId    string
+	MD5       string
+	// Skipped is true for the symbolic links left out by the symlink policy
+	Skipped bool
+	// Resumed is true when the file was uploaded by a previous run, see params.WithJournal
+	Resumed bool
+	Err     error
+}
+
+// Walk calls fn for the directory and every directory under it, parents first.
+func (d *UploadedDir) Walk(fn func(dir *UploadedDir)) {
+	fn(d)
+	for _, folder := range d.Folders {
+		folder.Walk(fn)
+	}
+}
+
+// Ids maps the local path of every uploaded file and created folder to its content id.
+func (d *UploadedDir) Ids() map[string]string {
+	ids := map[string]string{}
+	d.Walk(func(dir *UploadedDir) {
+		if dir.FolderId != "" {
+			ids[dir.LocalPath] = dir.FolderId
+		}
+		for _, file := range dir.Files {
+			if file.FileId != "" {
+				ids[file.LocalPath] = file.FileId
+			}
+		}
+	})
+	return ids
+}
+
+// Errs joins the errors of every directory and file, it is nil when everything was uploaded.
+func (d *UploadedDir) Errs() error {
+	errs := []error{}
+	d.Walk(func(dir *UploadedDir) {
+		if dir.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", dir.LocalPath, dir.Err))
+		}
+		for _, file := range dir.Files {
+			if file.Err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", file.LocalPath, file.Err))
+			}
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// UploadResult is the result of UploadFiles for one of its sources.
+type UploadResult struct {
+	File *UploadedFile
+	Err  error
+}
+
+// DownloadedDir is the result of DownloadFolder for a remote folder and the local directory it was written to.
+type DownloadedDir struct {
+	FolderId  string
+	LocalPath string
+	// Err is set when the folder could not be listed or the directory could not be created,
+	// its content is then not downloaded
+	Err     error
+	Files   []DownloadedFile
+	Folders []*DownloadedDir
+}
+
+type DownloadedFile struct {
+	FileId    string
+	LocalPath string
+	Size      int
+	MD5       string
+	// Skipped is true when the local file already had the md5 of the remote file
+	Skipped bool
+	Err     error
+}
+
+// Walk calls fn for the directory and every directory under it, parents first.
+func (d *DownloadedDir) Walk(fn func(dir *DownloadedDir)) {
+	fn(d)
+	for _, folder := range d.Folders {
+		folder.Walk(fn)
+	}
+}
+
+// Errs joins the errors of every directory and file, it is nil when everything was downloaded.
+func (d *DownloadedDir) Errs() error {
+	errs := []error{}
+	d.Walk(func(dir *DownloadedDir) {
+		if dir.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", dir.LocalPath, dir.Err))
+		}
+		for _, file := range dir.Files {
+			if file.Err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", file.LocalPath, file.Err))
+			}
+		}
+	})
+	return errors.Join(errs...)
+}
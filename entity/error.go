@@ -0,0 +1,181 @@
+package entity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// APIError is returned when the api answers with an error, it keeps the
+// http status code, the endpoint and the raw api status. errors.Is matches
+// it against the sentinel of its status (ErrToken, ErrNotPremium, ...).
+type APIError struct {
+	StatusCode int
+	Method     string
+	Endpoint   string
+	Status     string
+	ContentId  string
+	Body       string
+}
+
+// NewAPIError reads the api status from body, a body without status
+// (e.g. a 502 page from a proxy) leaves Status empty.
+func NewAPIError(statusCode int, method, endpoint, contentId string, body []byte) *APIError {
+	status := ""
+	p := responseParserPool.Get()
+	if v, err := p.ParseBytes(body); err == nil {
+		status = string(v.GetStringBytes("status"))
+	}
+	responseParserPool.Put(p)
+	return &APIError{
+		StatusCode: statusCode,
+		Method:     method,
+		Endpoint:   endpoint,
+		Status:     status,
+		ContentId:  contentId,
+		Body:       string(body),
+	}
+}
+
+func (e *APIError) Error() string {
+	status := e.Status
+	if status == "" {
+		status = http.StatusText(e.StatusCode)
+	}
+	if e.ContentId != "" {
+		return fmt.Sprintf("%s: %s %s (%d, content %s)", status, e.Method, e.Endpoint, e.StatusCode, e.ContentId)
+	}
+	return fmt.Sprintf("%s: %s %s (%d)", status, e.Method, e.Endpoint, e.StatusCode)
+}
+
+func (e *APIError) Unwrap() error {
+	if e.Status == "" || e.Status == "ok" {
+		return nil
+	}
+	return ErrorResponseStatus(e.Status)
+}
+
+func (e *APIError) IsRateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.Status == ErrRateLimit.Error()
+}
+
+// IsRetryable reports whether the same request may succeed later,
+// which is the case for rate limits and server side failures.
+func (e *APIError) IsRetryable() bool {
+	if e.IsRateLimited() {
+		return true
+	}
+	switch e.StatusCode {
+	case http.StatusRequestTimeout, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// IsRetryable reports whether err is a transient failure: a retryable
+// *APIError, a network timeout or a dropped connection.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.IsRetryable()
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+type UploadAttempt struct {
+	Server   string
+	Err      error
+	Duration time.Duration
+}
+
+// UploadError is returned when an upload failed on every server it was sent to.
+type UploadError struct {
+	Attempts []UploadAttempt
+}
+
+func (e *UploadError) Error() string {
+	failures := []string{}
+	for _, attempt := range e.Attempts {
+		failures = append(failures, fmt.Sprintf("%s: %v", attempt.Server, attempt.Err))
+	}
+	return fmt.Sprintf("upload failed on %d servers: %s", len(e.Attempts), strings.Join(failures, "; "))
+}
+
+func (e *UploadError) Unwrap() []error {
+	errs := []error{}
+	for _, attempt := range e.Attempts {
+		errs = append(errs, attempt.Err)
+	}
+	return errs
+}
+
+// ChecksumError is returned when the md5 of an uploaded file differs from the
+// md5 of the data sent, errors.Is matches it against ErrChecksumMismatch.
+type ChecksumError struct {
+	FileId   string
+	Server   string
+	Expected string
+	Actual   string
+	// Deleted is true when the remote file was deleted, see params.WithDeleteOnChecksumMismatch
+	Deleted bool
+}
+
+func (e *ChecksumError) Error() string {
+	if e.Server == "" {
+		return fmt.Sprintf("%s: file %s has md5 %s, expected %s", ErrChecksumMismatch, e.FileId, e.Actual, e.Expected)
+	}
+	return fmt.Sprintf("%s: file %s on %s has md5 %s, sent %s", ErrChecksumMismatch, e.FileId, e.Server, e.Actual, e.Expected)
+}
+
+func (e *ChecksumError) Unwrap() error {
+	return ErrChecksumMismatch
+}
+
+// PostUploadError is returned when a file was uploaded but setting an attribute of its
+// folder failed, File is the uploaded file and the attributes before Attribute were set.
+type PostUploadError struct {
+	File      UploadedFile
+	FolderId  string
+	Attribute string
+	Err       error
+}
+
+func (e *PostUploadError) Error() string {
+	return fmt.Sprintf("file %s uploaded, setting the %s of folder %s failed: %v", e.File.FileId, e.Attribute, e.FolderId, e.Err)
+}
+
+func (e *PostUploadError) Unwrap() error {
+	return e.Err
+}
+
+// ShareURLError is returned when a share url or a content code is not recognized,
+// errors.Is matches it against ErrShareURL.
+type ShareURLError struct {
+	URL    string
+	Reason string
+}
+
+func (e *ShareURLError) Error() string {
+	return fmt.Sprintf("%s: %q %s", ErrShareURL, e.URL, e.Reason)
+}
+
+func (e *ShareURLError) Unwrap() error {
+	return ErrShareURL
+}
@@ -0,0 +1,29 @@
+package entity
+
+// LargeManifest describes a file uploaded in parts by UploadLarge, it is stored as
+// json next to the parts so DownloadLarge can reassemble and verify the file.
+type LargeManifest struct {
+	Version  int         `json:"version"`
+	FileName string      `json:"fileName"`
+	Size     int64       `json:"size"`
+	PartSize int64       `json:"partSize"`
+	SHA256   string      `json:"sha256"`
+	Parts    []LargePart `json:"parts"`
+}
+
+// LargePart is a part of a large file, in the order of the file.
+type LargePart struct {
+	Index    int    `json:"index"`
+	FileName string `json:"fileName"`
+	FileId   string `json:"fileId"`
+	Size     int64  `json:"size"`
+	MD5      string `json:"md5"`
+}
+
+type LargeUpload struct {
+	// FolderId is the folder created for the parts and the manifest
+	FolderId   string
+	Code       string
+	ManifestId string
+	Manifest   LargeManifest
+}
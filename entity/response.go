@@ -14,10 +14,18 @@ var (
 	ErrorNotFound   = errors.New("error-notFound")
 	ErrorContentsId = errors.New("error-contentsId")
 	ErrorType       = errors.New("error-type")
+	ErrRateLimit    = errors.New("error-rateLimit")
 	// Custom errors
-	ErrEmptyStatus    = errors.New("error-emptyStatus")
-	ErrPrivateContent = errors.New("error-privateContent")
-	ErrAccount        = errors.New("error-account")
+	ErrEmptyStatus       = errors.New("error-emptyStatus")
+	ErrPrivateContent    = errors.New("error-privateContent")
+	ErrAccount           = errors.New("error-account")
+	ErrNoServerAvailable = errors.New("error-noServerAvailable")
+	ErrChecksumMismatch  = errors.New("error-checksumMismatch")
+	ErrShareURL          = errors.New("error-shareURL")
+	// ErrPasswordRequired and ErrWrongPassword are returned for the passwordStatus of a content
+	// protected by a password, see params.WithContentPassword
+	ErrPasswordRequired = errors.New("error-passwordRequired")
+	ErrWrongPassword    = errors.New("error-passwordWrong")
 )
 
 var responseParserPool fastjson.ParserPool
@@ -141,6 +149,8 @@ func ErrorResponseStatus(status string) error {
 		return ErrorContentsId
 	case "error-type":
 		return ErrorType
+	case "error-rateLimit":
+		return ErrRateLimit
 	case "error-account":
 		return ErrAccount
 	case "":
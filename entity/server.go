@@ -1,5 +1,7 @@
 package entity
 
+import "time"
+
 type Server struct {
 	Name string `json:"name"`
 	Zone string `json:"zone"`
@@ -8,3 +10,21 @@ type Server struct {
 type Servers struct {
 	Servers []Server `json:"servers"`
 }
+
+// ServerHealth is what the client knows about an upload server from its
+// probes and uploads.
+type ServerHealth struct {
+	Name        string
+	Zone        string
+	Healthy     bool
+	Failures    int
+	LastLatency time.Duration
+	LastError   string
+	LastChecked time.Time
+	// CoolingUntil is set after a failure, the server is skipped until then.
+	CoolingUntil time.Time
+}
+
+func (h ServerHealth) IsCooling(now time.Time) bool {
+	return h.Failures > 0 && now.Before(h.CoolingUntil)
+}
@@ -0,0 +1,70 @@
+package gofile_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dvwzj/gofile"
+	"github.com/dvwzj/gofile/entity"
+	"github.com/dvwzj/gofile/gofiletest"
+)
+
+func TestAPIError(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = client.DeleteContent("content-id")
+	if !errors.Is(err, entity.ErrToken) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var apiErr *entity.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("unexpected error type: %T", err)
+	}
+	if apiErr.StatusCode != http.StatusUnauthorized || apiErr.Method != http.MethodDelete || apiErr.Endpoint != "/contents/content-id" || apiErr.ContentId != "content-id" || apiErr.Status != "error-token" {
+		t.Fatalf("unexpected api error: %+v", apiErr)
+	}
+	if apiErr.IsRetryable() || apiErr.IsRateLimited() {
+		t.Fatalf("unexpected retryable error: %+v", apiErr)
+	}
+}
+
+func TestAPIErrorRetryable(t *testing.T) {
+	tests := []struct {
+		statusCode  int
+		body        string
+		retryable   bool
+		rateLimited bool
+	}{
+		{http.StatusBadGateway, "<html>bad gateway</html>", true, false},
+		{http.StatusTooManyRequests, `{"status":"error-rateLimit"}`, true, true},
+		{http.StatusNotFound, `{"status":"error-notFound"}`, false, false},
+	}
+	for _, test := range tests {
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(test.statusCode)
+			w.Write([]byte(test.body))
+		}))
+		client, err := gofile.NewClient(gofile.WithBaseURL(proxy.URL))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_, err = client.GetServers()
+		proxy.Close()
+		var apiErr *entity.APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if apiErr.StatusCode != test.statusCode || apiErr.Body != test.body {
+			t.Fatalf("unexpected api error: %+v", apiErr)
+		}
+		if apiErr.IsRetryable() != test.retryable || apiErr.IsRateLimited() != test.rateLimited {
+			t.Fatalf("unexpected classification for %d: %+v", test.statusCode, apiErr)
+		}
+	}
+}
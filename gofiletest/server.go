@@ -0,0 +1,1093 @@
+package gofiletest
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dvwzj/gofile"
+	"github.com/dvwzj/gofile/entity"
+)
+
+var statusCodes = map[string]int{
+	entity.ErrToken.Error():          http.StatusUnauthorized,
+	entity.ErrWrongToken.Error():     http.StatusUnauthorized,
+	entity.ErrNotPremium.Error():     http.StatusForbidden,
+	entity.ErrPrivateContent.Error(): http.StatusForbidden,
+	entity.ErrorNotFound.Error():     http.StatusNotFound,
+}
+
+type account struct {
+	entity.Account
+}
+
+type directLink struct {
+	id string
+	entity.DirectLink
+}
+
+type content struct {
+	id          string
+	contentType entity.ContentType
+	name        string
+	parent      string
+	code        string
+	createTime  int
+	owner       string
+	public      bool
+	description string
+	tags        string
+	expiry      string
+	password    string
+	children    []string
+	data        []byte
+	md5         string
+	mimetype    string
+	server      string
+	downloads   int
+	directLinks map[string]*directLink
+}
+
+// Server is an in-memory gofile.io, both the api and the upload servers
+// are served by the same httptest.Server.
+type Server struct {
+	*httptest.Server
+	mu       sync.Mutex
+	servers  []entity.Server
+	accounts map[string]*account
+	tokens   map[string]string
+	contents map[string]*content
+	failures map[string][]int
+	cuts     map[string][]int
+	blocks   map[string]int
+	requests map[string]int
+	corrupt  int
+}
+
+func NewServer() *Server {
+	s := &Server{
+		servers: []entity.Server{
+			{Name: "store1", Zone: "eu"},
+			{Name: "store2", Zone: "na"},
+		},
+		accounts: map[string]*account{},
+		tokens:   map[string]string{},
+		contents: map[string]*content{},
+		failures: map[string][]int{},
+		cuts:     map[string][]int{},
+		blocks:   map[string]int{},
+		requests: map[string]int{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /servers", s.handleGetServers)
+	mux.HandleFunc("HEAD /store/{server}", s.handleHeadServer)
+	mux.HandleFunc("POST /store/{server}/contents/uploadfile", s.handleUploadFile)
+	mux.HandleFunc("GET /store/{server}/download/web/{fileId}/{fileName}", s.handleDownload)
+	mux.HandleFunc("POST /contents/createFolder", s.handleCreateFolder)
+	mux.HandleFunc("PUT /contents/{contentId}/update", s.handleUpdateContent)
+	mux.HandleFunc("DELETE /contents", s.handleDeleteContents)
+	mux.HandleFunc("DELETE /contents/{contentId}", s.handleDeleteContent)
+	mux.HandleFunc("GET /contents/{contentId}", s.handleGetContent)
+	mux.HandleFunc("POST /contents/{contentId}/directlinks", s.handleCreateDirectLink)
+	mux.HandleFunc("PUT /contents/{contentId}/directlinks/{directLinkId}", s.handleUpdateDirectLink)
+	mux.HandleFunc("DELETE /contents/{contentId}/directlinks/{directLinkId}", s.handleDeleteDirectLink)
+	mux.HandleFunc("POST /contents/copy", s.handleCopyContents)
+	mux.HandleFunc("POST /contents/{contentId}/copy", s.handleCopyContent)
+	mux.HandleFunc("PUT /contents/move", s.handleMoveContents)
+	mux.HandleFunc("PUT /contents/{contentId}/move", s.handleMoveContent)
+	mux.HandleFunc("POST /accounts", s.handleCreateAccount)
+	mux.HandleFunc("GET /accounts/getid", s.handleGetAccountId)
+	mux.HandleFunc("GET /accounts/{accountId}", s.handleGetAccount)
+	mux.HandleFunc("POST /accounts/{accountId}/resettoken", s.handleResetAccountToken)
+	s.Server = httptest.NewServer(s.intercept(mux))
+	return s
+}
+
+// FailNext makes the next requests to method and path (e.g. "POST", "/store/store1/contents/uploadfile")
+// answer with statusCode and no api status, a statusCode of 0 drops the connection instead.
+func (s *Server) FailNext(method, path string, statusCode, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < times; i++ {
+		s.failures[method+" "+path] = append(s.failures[method+" "+path], statusCode)
+	}
+}
+
+// CutNext drops the connection of the next requests to method and path once n bytes
+// of the response body were sent, e.g. to interrupt a download.
+func (s *Server) CutNext(method, path string, n, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < times; i++ {
+		s.cuts[method+" "+path] = append(s.cuts[method+" "+path], n)
+	}
+}
+
+// BlockNext makes the next requests to method and path hang without answer until
+// the client gives up on them, e.g. to cancel a request in flight.
+func (s *Server) BlockNext(method, path string, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blocks[method+" "+path] += times
+}
+
+// CorruptNext flips the first byte of the next uploaded files before they are stored,
+// the md5 answered is then the one of the corrupted data.
+func (s *Server) CorruptNext(times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.corrupt += times
+}
+
+// Requests returns how many requests were received for method and path.
+func (s *Server) Requests(method, path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[method+" "+path]
+}
+
+func (s *Server) intercept(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Method + " " + r.URL.Path
+		s.mu.Lock()
+		s.requests[key]++
+		if s.blocks[key] > 0 {
+			s.blocks[key]--
+			s.mu.Unlock()
+			// the server only notices the client going away once the body is read
+			io.Copy(io.Discard, r.Body)
+			<-r.Context().Done()
+			return
+		}
+		failures := s.failures[key]
+		if len(failures) == 0 {
+			if cuts := s.cuts[key]; len(cuts) > 0 {
+				s.cuts[key] = cuts[1:]
+				s.mu.Unlock()
+				next.ServeHTTP(&cutWriter{ResponseWriter: w, remaining: cuts[0]}, r)
+				return
+			}
+			s.mu.Unlock()
+			next.ServeHTTP(w, r)
+			return
+		}
+		statusCode := failures[0]
+		s.failures[key] = failures[1:]
+		s.mu.Unlock()
+		if statusCode == 0 {
+			if hijacker, ok := w.(http.Hijacker); ok {
+				if conn, _, err := hijacker.Hijack(); err == nil {
+					conn.Close()
+					return
+				}
+			}
+		}
+		w.WriteHeader(statusCode)
+	})
+}
+
+// cutWriter drops the connection once remaining bytes were written.
+type cutWriter struct {
+	http.ResponseWriter
+	remaining int
+	cut       bool
+}
+
+func (w *cutWriter) Write(p []byte) (int, error) {
+	if w.cut {
+		return 0, http.ErrHijacked
+	}
+	if len(p) < w.remaining {
+		w.remaining -= len(p)
+		return w.ResponseWriter.Write(p)
+	}
+	n, _ := w.ResponseWriter.Write(p[:w.remaining])
+	w.cut = true
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		if conn, _, err := hijacker.Hijack(); err == nil {
+			conn.Close()
+		}
+	}
+	return n, http.ErrHijacked
+}
+
+// UploadURLTemplate returns the template to pass to gofile.WithUploadURLTemplate.
+func (s *Server) UploadURLTemplate() string {
+	return s.URL + "/store/{server}"
+}
+
+// ClientOptions returns the options pointing a client at this server.
+func (s *Server) ClientOptions() []gofile.ClientOption {
+	return []gofile.ClientOption{
+		gofile.WithBaseURL(s.URL),
+		gofile.WithUploadURLTemplate(s.UploadURLTemplate()),
+	}
+}
+
+// NewClient returns a client pointed at this server, options are applied
+// after the server ones.
+func (s *Server) NewClient(options ...gofile.ClientOption) (gofile.Client, error) {
+	return gofile.NewClient(append(s.ClientOptions(), options...)...)
+}
+
+func (s *Server) SetServers(servers ...entity.Server) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.servers = servers
+}
+
+// AddAccount registers an account, an empty Id, Token, Tier or RootFolder is generated.
+func (s *Server) AddAccount(a entity.Account) entity.Account {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addAccount(a).Account
+}
+
+func (s *Server) NewAccount(tier entity.AccountTier) entity.Account {
+	return s.AddAccount(entity.Account{Tier: tier})
+}
+
+func (s *Server) Account(accountId string) (entity.Account, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.accounts[accountId]
+	if !ok {
+		return entity.Account{}, false
+	}
+	return s.accountStats(a), true
+}
+
+// FileData returns the bytes stored for an uploaded file.
+func (s *Server) FileData(fileId string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.contents[fileId]
+	if !ok || c.contentType != entity.ContentTypeFile {
+		return nil, false
+	}
+	return c.data, true
+}
+
+// FolderAttributes returns the attributes set with UpdateContent on a folder:
+// description, tags, public, expiry and password.
+func (s *Server) FolderAttributes(folderId string) (map[string]string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.contents[folderId]
+	if !ok || c.contentType != entity.ContentTypeFolder {
+		return nil, false
+	}
+	return map[string]string{
+		"description": c.description,
+		"tags":        c.tags,
+		"public":      strconv.FormatBool(c.public),
+		"expiry":      c.expiry,
+		"password":    c.password,
+	}, true
+}
+
+func (s *Server) addAccount(a entity.Account) *account {
+	if a.Id == "" {
+		a.Id = newId()
+	}
+	if a.Token == "" {
+		a.Token = newToken()
+	}
+	if a.Tier == "" {
+		a.Tier = entity.AccountTierGuest
+	}
+	if a.RootFolder == "" {
+		root := s.newContent(entity.ContentTypeFolder, "root", "", a.Id)
+		a.RootFolder = root.id
+	}
+	acc := &account{Account: a}
+	s.accounts[a.Id] = acc
+	s.tokens[a.Token] = a.Id
+	return acc
+}
+
+func (s *Server) accountStats(a *account) entity.Account {
+	stats := entity.AccountStatsCurrent{}
+	for _, c := range s.contents {
+		if c.owner != a.Id || c.id == a.RootFolder {
+			continue
+		}
+		if c.contentType == entity.ContentTypeFile {
+			stats.FileCount++
+			stats.Storage += len(c.data)
+		} else {
+			stats.FolderCount++
+		}
+	}
+	result := a.Account
+	result.StatsCurrent = stats
+	return result
+}
+
+func (s *Server) newContent(contentType entity.ContentType, name, parent, owner string) *content {
+	c := &content{
+		id:          newId(),
+		contentType: contentType,
+		name:        name,
+		parent:      parent,
+		code:        newCode(),
+		createTime:  int(time.Now().Unix()),
+		owner:       owner,
+		directLinks: map[string]*directLink{},
+	}
+	s.contents[c.id] = c
+	if p, ok := s.contents[parent]; ok {
+		p.children = append(p.children, c.id)
+	}
+	return c
+}
+
+func (s *Server) removeContent(c *content) {
+	for _, childId := range c.children {
+		if child, ok := s.contents[childId]; ok {
+			s.removeContent(child)
+		}
+	}
+	if p, ok := s.contents[c.parent]; ok {
+		p.children = removeId(p.children, c.id)
+	}
+	delete(s.contents, c.id)
+}
+
+func (s *Server) copyContent(c *content, parent string, owner string) *content {
+	copied := s.newContent(c.contentType, c.name, parent, owner)
+	copied.public = c.public
+	copied.description = c.description
+	copied.tags = c.tags
+	copied.data = c.data
+	copied.md5 = c.md5
+	copied.mimetype = c.mimetype
+	copied.server = c.server
+	for _, childId := range append([]string{}, c.children...) {
+		if child, ok := s.contents[childId]; ok {
+			s.copyContent(child, copied.id, owner)
+		}
+	}
+	return copied
+}
+
+func (s *Server) moveContent(c *content, folder *content) string {
+	for p := folder; p != nil; p = s.contents[p.parent] {
+		if p.id == c.id {
+			return entity.ErrorType.Error()
+		}
+	}
+	if p, ok := s.contents[c.parent]; ok {
+		p.children = removeId(p.children, c.id)
+	}
+	c.parent = folder.id
+	folder.children = append(folder.children, c.id)
+	return "ok"
+}
+
+// authorize returns the account of the bearer token, or the status to respond with.
+func (s *Server) authorize(r *http.Request) (*account, string) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		return nil, entity.ErrToken.Error()
+	}
+	accountId, ok := s.tokens[token]
+	if !ok {
+		return nil, entity.ErrWrongToken.Error()
+	}
+	return s.accounts[accountId], "ok"
+}
+
+func (s *Server) authorizePremium(r *http.Request) (*account, string) {
+	a, status := s.authorize(r)
+	if status != "ok" {
+		return nil, status
+	}
+	if a.Tier != entity.AccountTierPremium {
+		return nil, entity.ErrNotPremium.Error()
+	}
+	return a, "ok"
+}
+
+// ownedContent returns the content owned by the account, or the status to respond with.
+func (s *Server) ownedContent(a *account, contentId string) (*content, string) {
+	c, ok := s.contents[contentId]
+	if !ok || c.owner != a.Id {
+		return nil, entity.ErrorNotFound.Error()
+	}
+	return c, "ok"
+}
+
+func (s *Server) ownedFolder(a *account, folderId string) (*content, string) {
+	c, status := s.ownedContent(a, folderId)
+	if status != "ok" {
+		return nil, status
+	}
+	if c.contentType != entity.ContentTypeFolder {
+		return nil, entity.ErrorType.Error()
+	}
+	return c, "ok"
+}
+
+func (s *Server) link(c *content) string {
+	return strings.ReplaceAll(s.UploadURLTemplate(), "{server}", c.server) + "/download/web/" + c.id + "/" + c.name
+}
+
+func (s *Server) universalContent(c *content, withChildren bool) entity.UniversalContent {
+	u := entity.UniversalContent{
+		Id:         ptr(c.id),
+		Type:       ptr(c.contentType),
+		Name:       ptr(c.name),
+		CreateTime: ptr(c.createTime),
+	}
+	if c.parent != "" {
+		u.ParentFolder = ptr(c.parent)
+	}
+	if c.contentType == entity.ContentTypeFile {
+		u.Size = ptr(len(c.data))
+		u.DownloadCount = ptr(c.downloads)
+		u.MD5 = ptr(c.md5)
+		u.Mimetype = ptr(c.mimetype)
+		u.ServerSelected = ptr(c.server)
+		u.Link = ptr(s.link(c))
+		if len(c.directLinks) > 0 {
+			directLinks := map[string]entity.DirectLink{}
+			for id, dl := range c.directLinks {
+				directLinks[id] = dl.DirectLink
+			}
+			u.DirectLinks = &directLinks
+		}
+		return u
+	}
+	u.Code = ptr(c.code)
+	u.Public = ptr(c.public)
+	u.ChildrenIds = ptr(append([]string{}, c.children...))
+	totalSize, totalDownloadCount := s.totals(c)
+	u.TotalSize = ptr(totalSize)
+	u.TotalDownloadCount = ptr(totalDownloadCount)
+	if withChildren {
+		children := entity.ChildContent{}
+		for _, childId := range c.children {
+			if child, ok := s.contents[childId]; ok {
+				children[childId] = s.universalContent(child, false)
+			}
+		}
+		u.Children = &children
+	}
+	return u
+}
+
+func (s *Server) totals(c *content) (int, int) {
+	if c.contentType == entity.ContentTypeFile {
+		return len(c.data), c.downloads
+	}
+	size, downloads := 0, 0
+	for _, childId := range c.children {
+		if child, ok := s.contents[childId]; ok {
+			childSize, childDownloads := s.totals(child)
+			size += childSize
+			downloads += childDownloads
+		}
+	}
+	return size, downloads
+}
+
+func (s *Server) handleGetServers(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeData(w, entity.Servers{Servers: append([]entity.Server{}, s.servers...)})
+}
+
+func (s *Server) handleHeadServer(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.hasServer(r.PathValue("server")) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) hasServer(name string) bool {
+	for _, server := range s.servers {
+		if server.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) handleUploadFile(w http.ResponseWriter, r *http.Request) {
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeStatus(w, "error-noFile")
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		writeStatus(w, "error-upload")
+		return
+	}
+	folderId := r.FormValue("folderId")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	server := r.PathValue("server")
+	if !s.hasServer(server) {
+		writeStatus(w, entity.ErrorNotFound.Error())
+		return
+	}
+	var a *account
+	guestToken := ""
+	if r.Header.Get("Authorization") == "" && folderId == "" {
+		a = s.addAccount(entity.Account{Tier: entity.AccountTierGuest})
+		guestToken = a.Token
+	} else {
+		var status string
+		if a, status = s.authorize(r); status != "ok" {
+			writeStatus(w, status)
+			return
+		}
+	}
+	var folder *content
+	if folderId == "" {
+		folder = s.newContent(entity.ContentTypeFolder, newCode(), a.RootFolder, a.Id)
+	} else {
+		var status string
+		if folder, status = s.ownedFolder(a, folderId); status != "ok" {
+			writeStatus(w, status)
+			return
+		}
+	}
+	if s.corrupt > 0 && len(data) > 0 {
+		s.corrupt--
+		data[0] ^= 0xff
+	}
+	f := s.newContent(entity.ContentTypeFile, header.Filename, folder.id, a.Id)
+	f.data = data
+	sum := md5.Sum(data)
+	f.md5 = hex.EncodeToString(sum[:])
+	f.mimetype = http.DetectContentType(data)
+	f.server = server
+	result := map[string]interface{}{
+		"code":         folder.code,
+		"downloadPage": s.URL + "/d/" + folder.code,
+		"fileId":       f.id,
+		"fileName":     f.name,
+		"md5":          f.md5,
+		"parentFolder": folder.id,
+	}
+	if guestToken != "" {
+		result["guestToken"] = guestToken
+	}
+	writeData(w, result)
+}
+
+// handleDownload serves a file like the download links of gofile: the accountToken cookie
+// must be the token of an account, the file must be owned by it or be in a public folder.
+// Range requests are supported.
+func (s *Server) handleDownload(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	cookie, err := r.Cookie("accountToken")
+	if err != nil {
+		s.mu.Unlock()
+		writeStatus(w, entity.ErrToken.Error())
+		return
+	}
+	accountId, ok := s.tokens[cookie.Value]
+	if !ok {
+		s.mu.Unlock()
+		writeStatus(w, entity.ErrWrongToken.Error())
+		return
+	}
+	f, ok := s.contents[r.PathValue("fileId")]
+	if !ok || f.contentType != entity.ContentTypeFile || f.server != r.PathValue("server") || f.name != r.PathValue("fileName") {
+		s.mu.Unlock()
+		writeStatus(w, entity.ErrorNotFound.Error())
+		return
+	}
+	folder := s.contents[f.parent]
+	if f.owner != accountId && (folder == nil || !folder.public) {
+		s.mu.Unlock()
+		writeStatus(w, entity.ErrorNotFound.Error())
+		return
+	}
+	f.downloads++
+	data, name, modTime := f.data, f.name, time.Unix(int64(f.createTime), 0)
+	s.mu.Unlock()
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+name+"\"")
+	http.ServeContent(w, r, name, modTime, bytes.NewReader(data))
+}
+
+func (s *Server) handleCreateFolder(w http.ResponseWriter, r *http.Request) {
+	body := readBody(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, status := s.authorize(r)
+	if status != "ok" {
+		writeStatus(w, status)
+		return
+	}
+	parent, status := s.ownedFolder(a, body["parentFolderId"])
+	if status != "ok" {
+		writeStatus(w, status)
+		return
+	}
+	name := body["folderName"]
+	if name == "" {
+		name = newCode()
+	}
+	folder := s.newContent(entity.ContentTypeFolder, name, parent.id, a.Id)
+	writeData(w, entity.CreatedFolder{
+		FolderId:     folder.id,
+		Type:         folder.contentType,
+		Name:         folder.name,
+		ParentFolder: folder.parent,
+		CreateTime:   folder.createTime,
+		Code:         folder.code,
+	})
+}
+
+func (s *Server) handleUpdateContent(w http.ResponseWriter, r *http.Request) {
+	body := readBody(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, status := s.authorize(r)
+	if status != "ok" {
+		writeStatus(w, status)
+		return
+	}
+	c, status := s.ownedContent(a, r.PathValue("contentId"))
+	if status != "ok" {
+		writeStatus(w, status)
+		return
+	}
+	attribute, value := body["attribute"], body["attributeValue"]
+	if attribute != "name" && c.contentType != entity.ContentTypeFolder {
+		writeStatus(w, entity.ErrorType.Error())
+		return
+	}
+	switch attribute {
+	case "name":
+		c.name = value
+	case "description":
+		c.description = value
+	case "tags":
+		c.tags = value
+	case "public":
+		c.public = value == "true"
+	case "expiry":
+		c.expiry = value
+	case "password":
+		c.password = value
+	default:
+		writeStatus(w, "error-attribute")
+		return
+	}
+	writeData(w, struct{}{})
+}
+
+func (s *Server) handleDeleteContents(w http.ResponseWriter, r *http.Request) {
+	body := readBody(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, status := s.authorize(r)
+	if status != "ok" {
+		writeStatus(w, status)
+		return
+	}
+	if body["contentsId"] == "" {
+		writeStatus(w, entity.ErrorContentsId.Error())
+		return
+	}
+	results := map[string]entity.EmptyDataResponse{}
+	for _, contentId := range strings.Split(body["contentsId"], ",") {
+		c, status := s.ownedContent(a, contentId)
+		if status == "ok" {
+			s.removeContent(c)
+		}
+		results[contentId] = entity.EmptyDataResponse{Status: status}
+	}
+	writeData(w, results)
+}
+
+func (s *Server) handleDeleteContent(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, status := s.authorize(r)
+	if status != "ok" {
+		writeStatus(w, status)
+		return
+	}
+	c, status := s.ownedContent(a, r.PathValue("contentId"))
+	if status != "ok" {
+		writeStatus(w, status)
+		return
+	}
+	s.removeContent(c)
+	writeData(w, struct{}{})
+}
+
+func (s *Server) handleGetContent(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, status := s.authorizePremium(r)
+	if status != "ok" {
+		writeStatus(w, status)
+		return
+	}
+	c, ok := s.contents[r.PathValue("contentId")]
+	if !ok {
+		// like gofile, a folder is also found by its code
+		c, ok = s.folderByCode(r.PathValue("contentId"))
+	}
+	if !ok {
+		writeStatus(w, entity.ErrorNotFound.Error())
+		return
+	}
+	isOwner := c.owner == a.Id
+	if !isOwner && !c.public {
+		writeStatus(w, entity.ErrPrivateContent.Error())
+		return
+	}
+	// like gofile, the password is sent as its sha256 hex digest and the owner does not need it,
+	// without the right one the data only tells the password status
+	if !isOwner && c.password != "" {
+		password := r.URL.Query().Get("password")
+		sum := sha256.Sum256([]byte(c.password))
+		passwordStatus := "passwordOk"
+		if password == "" {
+			passwordStatus = "passwordRequired"
+		} else if password != hex.EncodeToString(sum[:]) {
+			passwordStatus = "passwordWrong"
+		}
+		if passwordStatus != "passwordOk" {
+			writeData(w, entity.UniversalContent{
+				Type:           ptr(c.contentType),
+				Password:       ptr(true),
+				PasswordStatus: ptr(passwordStatus),
+			})
+			return
+		}
+	}
+	u := s.universalContent(c, true)
+	if c.password != "" {
+		u.Password = ptr(true)
+		if !isOwner {
+			u.PasswordStatus = ptr("passwordOk")
+		}
+	}
+	u.IsOwner = ptr(isOwner)
+	if isOwner && s.accounts[c.owner].RootFolder == c.id {
+		u.IsRoot = ptr(true)
+	}
+	writeData(w, u)
+}
+
+func (s *Server) handleCreateDirectLink(w http.ResponseWriter, r *http.Request) {
+	dl := entity.DirectLink{}
+	json.NewDecoder(r.Body).Decode(&dl)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, status := s.authorizePremium(r)
+	if status != "ok" {
+		writeStatus(w, status)
+		return
+	}
+	c, status := s.ownedContent(a, r.PathValue("contentId"))
+	if status != "ok" {
+		writeStatus(w, status)
+		return
+	}
+	id := newId()
+	server := c.server
+	if server == "" && len(s.servers) > 0 {
+		server = s.servers[0].Name
+	}
+	dl.DirectLink = strings.ReplaceAll(s.UploadURLTemplate(), "{server}", server) + "/download/direct/" + id + "/" + c.name
+	c.directLinks[id] = &directLink{id: id, DirectLink: dl}
+	writeData(w, directLinkData(id, dl))
+}
+
+func (s *Server) handleUpdateDirectLink(w http.ResponseWriter, r *http.Request) {
+	update := entity.DirectLink{}
+	json.NewDecoder(r.Body).Decode(&update)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, status := s.authorizePremium(r)
+	if status != "ok" {
+		writeStatus(w, status)
+		return
+	}
+	c, status := s.ownedContent(a, r.PathValue("contentId"))
+	if status != "ok" {
+		writeStatus(w, status)
+		return
+	}
+	dl, ok := c.directLinks[r.PathValue("directLinkId")]
+	if !ok {
+		writeStatus(w, entity.ErrorNotFound.Error())
+		return
+	}
+	update.DirectLink = dl.DirectLink.DirectLink
+	dl.DirectLink = update
+	writeData(w, directLinkData(dl.id, dl.DirectLink))
+}
+
+func (s *Server) handleDeleteDirectLink(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, status := s.authorizePremium(r)
+	if status != "ok" {
+		writeStatus(w, status)
+		return
+	}
+	c, status := s.ownedContent(a, r.PathValue("contentId"))
+	if status != "ok" {
+		writeStatus(w, status)
+		return
+	}
+	if _, ok := c.directLinks[r.PathValue("directLinkId")]; !ok {
+		writeStatus(w, entity.ErrorNotFound.Error())
+		return
+	}
+	delete(c.directLinks, r.PathValue("directLinkId"))
+	writeData(w, struct{}{})
+}
+
+func (s *Server) handleCopyContents(w http.ResponseWriter, r *http.Request) {
+	body := readBody(r)
+	s.copyContents(w, r, body["folderId"], strings.Split(body["contentsId"], ","))
+}
+
+func (s *Server) handleCopyContent(w http.ResponseWriter, r *http.Request) {
+	body := readBody(r)
+	s.copyContents(w, r, body["folderId"], []string{r.PathValue("contentId")})
+}
+
+func (s *Server) copyContents(w http.ResponseWriter, r *http.Request, folderId string, contentsId []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, status := s.authorizePremium(r)
+	if status != "ok" {
+		writeStatus(w, status)
+		return
+	}
+	folder, status := s.ownedFolder(a, folderId)
+	if status != "ok" {
+		writeStatus(w, status)
+		return
+	}
+	contents := []*content{}
+	for _, contentId := range contentsId {
+		c, status := s.ownedContent(a, contentId)
+		if status != "ok" {
+			writeStatus(w, status)
+			return
+		}
+		contents = append(contents, c)
+	}
+	for _, c := range contents {
+		s.copyContent(c, folder.id, a.Id)
+	}
+	writeData(w, struct{}{})
+}
+
+func (s *Server) handleMoveContents(w http.ResponseWriter, r *http.Request) {
+	body := readBody(r)
+	s.moveContents(w, r, body["folderId"], strings.Split(body["contentsId"], ","))
+}
+
+func (s *Server) handleMoveContent(w http.ResponseWriter, r *http.Request) {
+	body := readBody(r)
+	s.moveContents(w, r, body["folderId"], []string{r.PathValue("contentId")})
+}
+
+func (s *Server) moveContents(w http.ResponseWriter, r *http.Request, folderId string, contentsId []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, status := s.authorize(r)
+	if status != "ok" {
+		writeStatus(w, status)
+		return
+	}
+	folder, status := s.ownedFolder(a, folderId)
+	if status != "ok" {
+		writeStatus(w, status)
+		return
+	}
+	for _, contentId := range contentsId {
+		c, status := s.ownedContent(a, contentId)
+		if status != "ok" {
+			writeStatus(w, status)
+			return
+		}
+		if status := s.moveContent(c, folder); status != "ok" {
+			writeStatus(w, status)
+			return
+		}
+	}
+	writeData(w, struct{}{})
+}
+
+func (s *Server) handleCreateAccount(w http.ResponseWriter, r *http.Request) {
+	body := readBody(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tier := entity.AccountTierGuest
+	if body["email"] != "" {
+		tier = entity.AccountTierStandard
+	}
+	a := s.addAccount(entity.Account{Email: body["email"], Tier: tier})
+	writeData(w, entity.CreatedAccount{Id: a.Id, Token: a.Token})
+}
+
+func (s *Server) handleGetAccountId(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, status := s.authorize(r)
+	if status != "ok" {
+		writeStatus(w, status)
+		return
+	}
+	writeData(w, entity.GetId{Id: a.Id})
+}
+
+func (s *Server) handleGetAccount(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, status := s.authorize(r)
+	if status != "ok" {
+		writeStatus(w, status)
+		return
+	}
+	if a.Id != r.PathValue("accountId") {
+		writeStatus(w, entity.ErrorNotFound.Error())
+		return
+	}
+	writeData(w, s.accountStats(a))
+}
+
+func (s *Server) handleResetAccountToken(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, status := s.authorize(r)
+	if status != "ok" {
+		writeStatus(w, status)
+		return
+	}
+	if a.Id != r.PathValue("accountId") {
+		writeStatus(w, entity.ErrorNotFound.Error())
+		return
+	}
+	delete(s.tokens, a.Token)
+	a.Token = newToken()
+	s.tokens[a.Token] = a.Id
+	writeData(w, struct{}{})
+}
+
+func directLinkData(id string, dl entity.DirectLink) map[string]interface{} {
+	b, _ := json.Marshal(dl)
+	data := map[string]interface{}{}
+	json.Unmarshal(b, &data)
+	data["id"] = id
+	return data
+}
+
+// readBody decodes a json body into strings, so booleans and numbers
+// are read the same way as the api reads form values.
+func readBody(r *http.Request) map[string]string {
+	raw := map[string]interface{}{}
+	json.NewDecoder(r.Body).Decode(&raw)
+	body := map[string]string{}
+	for k, v := range raw {
+		switch v := v.(type) {
+		case string:
+			body[k] = v
+		case float64:
+			body[k] = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			body[k] = strconv.FormatBool(v)
+		}
+	}
+	return body
+}
+
+func writeData(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entity.Response[interface{}]{
+		Status: "ok",
+		Data:   data,
+	})
+}
+
+func writeStatus(w http.ResponseWriter, status string) {
+	code, ok := statusCodes[status]
+	if !ok {
+		code = http.StatusBadRequest
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(entity.Response[struct{}]{
+		Status: status,
+	})
+}
+
+func removeId(ids []string, id string) []string {
+	result := []string{}
+	for _, v := range ids {
+		if v != id {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func newId() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+func newToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (s *Server) folderByCode(code string) (*content, bool) {
+	for _, c := range s.contents {
+		if c.contentType == entity.ContentTypeFolder && c.code == code {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+func newCode() string {
+	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	b := make([]byte, 6)
+	rand.Read(b)
+	for i := range b {
+		b[i] = letters[int(b[i])%len(letters)]
+	}
+	return string(b)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
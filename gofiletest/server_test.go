@@ -0,0 +1,143 @@
+package gofiletest_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/dvwzj/gofile"
+	"github.com/dvwzj/gofile/entity"
+	"github.com/dvwzj/gofile/gofiletest"
+	"github.com/dvwzj/gofile/params"
+)
+
+func TestServerPremium(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	account := server.NewAccount(entity.AccountTierPremium)
+	client, err := server.NewClient(gofile.WithToken(account.Token))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	folder, err := client.CreateFolder(account.RootFolder, params.WithFolderName("folder"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if folder.Name != "folder" || folder.ParentFolder != account.RootFolder {
+		t.Fatalf("unexpected folder: %+v", folder)
+	}
+	uploadedFile, err := client.UploadFile(params.WithBytes([]byte("hello"), "hello.txt"), params.WithFolderId(folder.FolderId))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if uploadedFile.ParentFolder != folder.FolderId || uploadedFile.MD5 != "5d41402abc4b2a76b9719d911017c592" {
+		t.Fatalf("unexpected uploaded file: %+v", uploadedFile)
+	}
+	data, ok := server.FileData(uploadedFile.FileId)
+	if !ok || !bytes.Equal(data, []byte("hello")) {
+		t.Fatalf("unexpected file data: %q", data)
+	}
+	if err := client.UpdateContent(uploadedFile.FileId, params.WithName("renamed.txt")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := client.UpdateContent(uploadedFile.FileId, params.WithPublic(true)); err == nil {
+		t.Fatalf("unexpected nil error")
+	}
+	if err := client.UpdateContent(folder.FolderId, params.WithDescription("description")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := client.GetContent(folder.FolderId)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	child := content.Child()[uploadedFile.FileId]
+	if child.Name == nil || *child.Name != "renamed.txt" || child.Size == nil || *child.Size != 5 {
+		t.Fatalf("unexpected child: %+v", child)
+	}
+	directLink, err := client.CreateDirectLink(uploadedFile.FileId, entity.DirectLink{ExpireTime: 1735689600})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if directLink.DirectLink == "" {
+		t.Fatalf("unexpected empty direct link")
+	}
+	other, err := client.CreateFolder(account.RootFolder)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := client.CopyContent(other.FolderId, uploadedFile.FileId); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := client.MoveContent(other.FolderId, folder.FolderId); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := client.MoveContent(folder.FolderId, other.FolderId); err == nil {
+		t.Fatalf("unexpected nil error")
+	}
+	content, err = client.GetContent(other.FolderId)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(content.ChildrenIds) != 2 || len(content.Children.Folders()) != 1 {
+		t.Fatalf("unexpected children: %v", content.ChildrenIds)
+	}
+	if _, err := client.DeleteContents([]string{other.FolderId, "missing"}); !errors.Is(err, entity.ErrorNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.GetContent(uploadedFile.FileId); !errors.Is(err, entity.ErrorNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestServerStandard(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	account := server.NewAccount(entity.AccountTierStandard)
+	client, err := server.NewClient(gofile.WithToken(account.Token))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.GetContent(account.RootFolder); !errors.Is(err, entity.ErrNotPremium) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.CreateDirectLink(account.RootFolder, entity.DirectLink{}); !errors.Is(err, entity.ErrNotPremium) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := client.GetAccount()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Id != account.Id || got.Tier != entity.AccountTierStandard || got.RootFolder != account.RootFolder {
+		t.Fatalf("unexpected account: %+v", got)
+	}
+	if err := client.ResetAccountToken(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.GetAccountId(); !errors.Is(err, entity.ErrWrongToken) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reset, _ := server.Account(account.Id)
+	if reset.Token == account.Token {
+		t.Fatalf("token was not reset")
+	}
+}
+
+func TestServerServers(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	server.SetServers(entity.Server{Name: "store9", Zone: "eu"})
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	servers, err := client.GetServers()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(servers.Servers) != 1 || servers.Servers[0].Name != "store9" {
+		t.Fatalf("unexpected servers: %+v", servers)
+	}
+	if _, err := client.UploadFile(params.WithBytes([]byte("ok"), "ok.txt"), params.WithServerName("store1")); !errors.Is(err, entity.ErrorNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package params
+
+import (
+	"errors"
+
+	"github.com/dvwzj/gofile/encryption"
+	"github.com/dvwzj/gofile/entity"
+)
+
+const (
+	// DefaultDownloadWorkers is the number of parts DownloadLarge, or files DownloadFolder, downloads at the same time.
+	DefaultDownloadWorkers = 4
+	// DefaultResumeAttempts is the number of times an interrupted download is resumed.
+	DefaultResumeAttempts = 3
+)
+
+type DownloadFileParams struct {
+	FileId *string
+	File   *entity.ChildContentFile
+}
+
+// DownloadFile is the file to download, by id or as listed in the children of its folder.
+type DownloadFile func(*DownloadFileParams) error
+
+// WithFileId downloads the file fileId, its link and md5 are found in the content of its folder.
+func WithFileId(fileId string) DownloadFile {
+	return func(params *DownloadFileParams) error {
+		if fileId == "" {
+			return errors.New("fileId is empty")
+		}
+		params.FileId = &fileId
+		return nil
+	}
+}
+
+// WithChildFile downloads a file listed by GetContent on its folder (Children.Files()).
+func WithChildFile(file entity.ChildContentFile) DownloadFile {
+	return func(params *DownloadFileParams) error {
+		if file.Link == "" {
+			return errors.New("file has no link")
+		}
+		params.File = &file
+		return nil
+	}
+}
+
+type DownloadParams struct {
+	// Workers is only used by DownloadLarge and DownloadFolder
+	Workers        int
+	ResumeAttempts int
+	Decryption     encryption.KeyProvider
+	// Segments is only used by DownloadToPath and DownloadFolder
+	Segments int
+}
+
+type DownloadOption func(*DownloadParams) error
+
+func WithDownloadWorkers(workers int) DownloadOption {
+	return func(params *DownloadParams) error {
+		if workers < 1 {
+			return errors.New("workers must be at least 1")
+		}
+		params.Workers = workers
+		return nil
+	}
+}
+
+// WithResumeAttempts resumes an interrupted download up to attempts times with a Range request, 0 never does.
+func WithResumeAttempts(attempts int) DownloadOption {
+	return func(params *DownloadParams) error {
+		if attempts < 0 {
+			return errors.New("attempts must be at least 0")
+		}
+		params.ResumeAttempts = attempts
+		return nil
+	}
+}
+
+// WithDecryption decrypts a file uploaded with WithEncryption once its md5 is checked,
+// and its name when it was uploaded with WithEncryptedFileName.
+func WithDecryption(keys encryption.KeyProvider) DownloadOption {
+	return func(params *DownloadParams) error {
+		if keys == nil {
+			return errors.New("keys is nil")
+		}
+		params.Decryption = keys
+		return nil
+	}
+}
+
+// WithSegments splits the download of a file in segments byte ranges fetched at the same time and
+// written in place, each one resumed on its own. The whole file is checked against its md5 once
+// every segment is written. A server that does not honor the Range requests gets a single stream.
+// The segments are written to the path + ".segments" file, which only becomes the part file once
+// complete, a segmented download interrupted by a crash starts over.
+func WithSegments(segments int) DownloadOption {
+	return func(params *DownloadParams) error {
+		if segments < 1 {
+			return errors.New("segments must be at least 1")
+		}
+		params.Segments = segments
+		return nil
+	}
+}
@@ -0,0 +1,31 @@
+package params
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+type GetContentParams struct {
+	Password *string
+}
+
+// Query returns the query parameters of the request, the password is sent as its sha256 hex digest
+// like the website does.
+func (p GetContentParams) Query() map[string]string {
+	query := map[string]string{}
+	if p.Password != nil {
+		sum := sha256.Sum256([]byte(*p.Password))
+		query["password"] = hex.EncodeToString(sum[:])
+	}
+	return query
+}
+
+type GetContentOption func(*GetContentParams)
+
+// WithContentPassword reads a content protected with WithPassword, without it such a content
+// fails with entity.ErrPasswordRequired, and with entity.ErrWrongPassword when password is wrong.
+func WithContentPassword(password string) GetContentOption {
+	return func(params *GetContentParams) {
+		params.Password = &password
+	}
+}
@@ -0,0 +1,46 @@
+package params
+
+import (
+	"errors"
+	"time"
+)
+
+const DefaultProgressInterval = 200 * time.Millisecond
+
+type Progress struct {
+	BytesSent int64
+	// TotalBytes is -1 when the size of the source is unknown
+	TotalBytes int64
+	// Speed is the throughput since the previous report, in bytes per second
+	Speed float64
+	// AverageSpeed is the throughput since the start, in bytes per second
+	AverageSpeed float64
+	// ETA is -1 when the size of the source is unknown
+	ETA     time.Duration
+	Elapsed time.Duration
+	Done    bool
+}
+
+type ProgressFunc func(Progress)
+
+// WithProgress calls progress at most once per DefaultProgressInterval (see WithProgressInterval)
+// while the file is sent, and once more when it is entirely sent.
+func WithProgress(progress ProgressFunc) UploadFileOption {
+	return func(params *UploadFileParams) error {
+		if progress == nil {
+			return errors.New("progress is nil")
+		}
+		params.Progress = progress
+		return nil
+	}
+}
+
+func WithProgressInterval(interval time.Duration) UploadFileOption {
+	return func(params *UploadFileParams) error {
+		if interval <= 0 {
+			return errors.New("interval must be positive")
+		}
+		params.ProgressInterval = interval
+		return nil
+	}
+}
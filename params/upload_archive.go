@@ -0,0 +1,101 @@
+package params
+
+import (
+	"errors"
+	"path"
+	"strings"
+)
+
+// ArchiveFormat is the format of the archive sent by UploadArchive.
+type ArchiveFormat string
+
+const (
+	ArchiveTarGz ArchiveFormat = "tar.gz"
+	ArchiveZip   ArchiveFormat = "zip"
+)
+
+type UploadArchiveParams struct {
+	// Include and Exclude are path.Match patterns, matched against the slash separated path
+	// of a file relative to the directory, or against its name when they have no slash
+	Include []string
+	Exclude []string
+	// FileName defaults to the name of the directory with the extension of the format
+	FileName *string
+	// FileOptions are given to the UploadFile of the archive
+	FileOptions []UploadFileOption
+}
+
+// Match reports whether the file at relPath is archived: it matches one of the Include
+// patterns, or there are none, and none of the Exclude patterns.
+func (p UploadArchiveParams) Match(relPath string) bool {
+	if matchAny(p.Exclude, relPath) {
+		return false
+	}
+	return len(p.Include) == 0 || matchAny(p.Include, relPath)
+}
+
+// Excluded reports whether the directory at relPath and its content are left out.
+func (p UploadArchiveParams) Excluded(relPath string) bool {
+	return matchAny(p.Exclude, relPath)
+}
+
+func matchAny(patterns []string, relPath string) bool {
+	for _, pattern := range patterns {
+		name := relPath
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(relPath)
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+type UploadArchiveOption func(*UploadArchiveParams) error
+
+func WithInclude(patterns ...string) UploadArchiveOption {
+	return func(params *UploadArchiveParams) error {
+		if err := validPatterns(patterns); err != nil {
+			return err
+		}
+		params.Include = append(params.Include, patterns...)
+		return nil
+	}
+}
+
+func WithExclude(patterns ...string) UploadArchiveOption {
+	return func(params *UploadArchiveParams) error {
+		if err := validPatterns(patterns); err != nil {
+			return err
+		}
+		params.Exclude = append(params.Exclude, patterns...)
+		return nil
+	}
+}
+
+func WithArchiveName(fileName string) UploadArchiveOption {
+	return func(params *UploadArchiveParams) error {
+		if fileName == "" {
+			return errors.New("fileName is empty")
+		}
+		params.FileName = &fileName
+		return nil
+	}
+}
+
+func WithArchiveFileOptions(options ...UploadFileOption) UploadArchiveOption {
+	return func(params *UploadArchiveParams) error {
+		params.FileOptions = append(params.FileOptions, options...)
+		return nil
+	}
+}
+
+func validPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
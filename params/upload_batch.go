@@ -0,0 +1,84 @@
+package params
+
+import "errors"
+
+const (
+	// DefaultUploadWorkers is the number of files UploadFiles, UploadDir and UploadLarge upload at the same time.
+	DefaultUploadWorkers = 4
+	// DefaultPartSize is the size of the parts of UploadLarge.
+	DefaultPartSize = 64 * 1024 * 1024
+)
+
+// SymlinkPolicy tells UploadDir what to do with the symbolic links of the tree.
+type SymlinkPolicy int
+
+const (
+	// SymlinkSkip leaves the links out, they are listed as skipped in the result
+	SymlinkSkip SymlinkPolicy = iota
+	// SymlinkFollow uploads the target of the links, a link to a directory
+	// that is already being uploaded (a cycle) fails
+	SymlinkFollow
+	// SymlinkFail records an error for every link
+	SymlinkFail
+)
+
+type UploadBatchParams struct {
+	Workers int
+	// SymlinkPolicy is only used by UploadDir
+	SymlinkPolicy SymlinkPolicy
+	// JournalPath is only used by UploadDir
+	JournalPath string
+	// PartSize is only used by UploadLarge, a part is held in memory while it is uploaded
+	PartSize int64
+	// FileOptions are given to every UploadFile, UploadDir sets the folderId
+	FileOptions []UploadFileOption
+}
+
+type UploadBatchOption func(*UploadBatchParams) error
+
+func WithWorkers(workers int) UploadBatchOption {
+	return func(params *UploadBatchParams) error {
+		if workers < 1 {
+			return errors.New("workers must be at least 1")
+		}
+		params.Workers = workers
+		return nil
+	}
+}
+
+func WithSymlinkPolicy(policy SymlinkPolicy) UploadBatchOption {
+	return func(params *UploadBatchParams) error {
+		params.SymlinkPolicy = policy
+		return nil
+	}
+}
+
+// WithJournal makes UploadDir record the folders it creates and the files it uploads in the file
+// at journalPath. A rerun with the same journal reuses the folders and skips the files that did not
+// change (same size and modification time), so only what is missing is uploaded.
+func WithJournal(journalPath string) UploadBatchOption {
+	return func(params *UploadBatchParams) error {
+		if journalPath == "" {
+			return errors.New("journalPath is empty")
+		}
+		params.JournalPath = journalPath
+		return nil
+	}
+}
+
+func WithPartSize(partSize int64) UploadBatchOption {
+	return func(params *UploadBatchParams) error {
+		if partSize < 1 {
+			return errors.New("partSize must be at least 1")
+		}
+		params.PartSize = partSize
+		return nil
+	}
+}
+
+func WithFileOptions(options ...UploadFileOption) UploadBatchOption {
+	return func(params *UploadBatchParams) error {
+		params.FileOptions = append(params.FileOptions, options...)
+		return nil
+	}
+}
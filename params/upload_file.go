@@ -1,13 +1,19 @@
 package params
 
 import (
+	"bufio"
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/dvwzj/gofile/encryption"
+	"github.com/dvwzj/gofile/selector"
 	"github.com/go-resty/resty/v2"
 )
 
@@ -15,7 +21,23 @@ type UploadFileParams struct {
 	FolderId   *string
 	FileName   *string
 	FileReader io.Reader
-	Server     *string
+	// FileSize is the number of bytes FileReader yields, nil when unknown
+	FileSize *int64
+	// Closer is closed once the upload is done, for the sources opened by the params
+	Closer           io.Closer
+	Server           *string
+	ServerNames      []string
+	Token            *string
+	ServerSelector   selector.ServerSelector
+	Progress         ProgressFunc
+	ProgressInterval time.Duration
+	Failover         *int
+	DeleteOnMismatch bool
+	SkipIfExists     SkipMatch
+	Encryption       encryption.KeyProvider
+	EncryptFileName  bool
+	// Attributes are set on the folder of the file once it is uploaded
+	Attributes []UpdateContentOption
 }
 
 type UploadFile func(*UploadFileParams) error
@@ -26,6 +48,9 @@ func WithFile(file *os.File) UploadFile {
 			return errors.New("file is nil")
 		}
 		params.FileReader = file
+		if size, ok := remainingSize(file); ok {
+			params.FileSize = &size
+		}
 		segments := strings.Split(file.Name(), string(os.PathSeparator))
 		fileName := segments[len(segments)-1]
 		params.FileName = &fileName
@@ -39,6 +64,10 @@ func WithReader(fileReader io.Reader, fileName string) UploadFile {
 			return errors.New("fileReader is nil")
 		}
 		params.FileReader = fileReader
+		if sized, ok := fileReader.(interface{ Len() int }); ok {
+			size := int64(sized.Len())
+			params.FileSize = &size
+		}
 		params.FileName = &fileName
 		return nil
 	}
@@ -50,11 +79,42 @@ func WithBytes(data []byte, fileName string) UploadFile {
 			return errors.New("data is nil")
 		}
 		params.FileReader = bytes.NewReader(data)
+		size := int64(len(data))
+		params.FileSize = &size
+		params.FileName = &fileName
+		return nil
+	}
+}
+
+// WithFS streams the file name of fsys (e.g. an embed.FS, a zip.Reader or an fstest.MapFS),
+// its size comes from fs.Stat. Files that implement io.Seeker can be retried and fail over.
+func WithFS(fsys fs.FS, name string) UploadFile {
+	return func(params *UploadFileParams) error {
+		if fsys == nil {
+			return errors.New("fsys is nil")
+		}
+		info, err := fs.Stat(fsys, name)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return fmt.Errorf("%s is a directory", name)
+		}
+		file, err := fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		params.Closer = file
+		params.FileReader = file
+		size := info.Size()
+		params.FileSize = &size
+		fileName := info.Name()
 		params.FileName = &fileName
 		return nil
 	}
 }
 
+// WithPath streams a local file, or the body of an http(s) url, without reading it in memory.
 func WithPath(filePath string) UploadFile {
 	return func(params *UploadFileParams) error {
 		if filePath == "" {
@@ -62,16 +122,21 @@ func WithPath(filePath string) UploadFile {
 		}
 		if strings.HasPrefix(filePath, "http") || strings.HasPrefix(filePath, "https") {
 			client := resty.New()
-			resp, err := client.R().SetDoNotParseResponse(false).Get(filePath)
+			resp, err := client.R().SetDoNotParseResponse(true).Get(filePath)
 			if err != nil {
 				return err
 			}
-			buf := new(bytes.Buffer)
-			_, err = buf.WriteString(resp.String())
-			if err != nil {
-				return err
+			body := resp.RawBody()
+			params.Closer = body
+			if resp.IsError() {
+				return fmt.Errorf("%s: %s", filePath, resp.Status())
+			}
+			reader := bufio.NewReader(body)
+			params.FileReader = reader
+			if resp.RawResponse.ContentLength >= 0 {
+				size := resp.RawResponse.ContentLength
+				params.FileSize = &size
 			}
-			params.FileReader = buf
 			fileName := resp.Header().Get("Content-Disposition")
 			if fileName != "" {
 				fileName = strings.Trim(strings.Split(fileName, "filename=")[1], "\"")
@@ -91,7 +156,8 @@ func WithPath(filePath string) UploadFile {
 				if contentType != "" {
 					fileName = fileName + "." + strings.Split(contentType, "/")[1]
 				} else {
-					detectedType := http.DetectContentType(buf.Bytes())
+					head, _ := reader.Peek(512)
+					detectedType := http.DetectContentType(head)
 					if detectedType != "application/octet-stream" {
 						fileName = fileName + "." + strings.Split(detectedType, "/")[1]
 					}
@@ -106,13 +172,11 @@ func WithPath(filePath string) UploadFile {
 			if err != nil {
 				return err
 			}
-			defer file.Close()
-			buf := new(bytes.Buffer)
-			_, err = buf.ReadFrom(file)
-			if err != nil {
-				return err
+			params.Closer = file
+			params.FileReader = file
+			if size, ok := remainingSize(file); ok {
+				params.FileSize = &size
 			}
-			params.FileReader = buf
 			segments := strings.Split(filePath, string(os.PathSeparator))
 			fileName := segments[len(segments)-1]
 			params.FileName = &fileName
@@ -121,6 +185,19 @@ func WithPath(filePath string) UploadFile {
 	}
 }
 
+// remainingSize returns the number of bytes left to read in a regular file.
+func remainingSize(file *os.File) (int64, bool) {
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return 0, false
+	}
+	offset, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, false
+	}
+	return info.Size() - offset, true
+}
+
 type UploadFileOption func(*UploadFileParams) error
 
 func WithFolderId(folderId string) UploadFileOption {
@@ -137,6 +214,137 @@ func WithServerName(serverName string) UploadFileOption {
 	}
 }
 
+// WithServerSelector chooses the upload server with serverSelector instead of the client one,
+// it is ignored when WithServerName or WithServerNames is given.
+func WithServerSelector(serverSelector selector.ServerSelector) UploadFileOption {
+	return func(params *UploadFileParams) error {
+		if serverSelector == nil {
+			return errors.New("serverSelector is nil")
+		}
+		params.ServerSelector = serverSelector
+		return nil
+	}
+}
+
+// WithServerNames uploads to the first of serverNames, in this order, the others are only used
+// by WithFailover. No server is probed nor selected, it is ignored when WithServerName is given.
+func WithServerNames(serverNames ...string) UploadFileOption {
+	return func(params *UploadFileParams) error {
+		if len(serverNames) == 0 {
+			return errors.New("serverNames is empty")
+		}
+		params.ServerNames = serverNames
+		return nil
+	}
+}
+
+// WithAuthToken uploads with token instead of the token of the client,
+// e.g. the guest token answered by a first upload.
+func WithAuthToken(token string) UploadFileOption {
+	return func(params *UploadFileParams) error {
+		params.Token = &token
+		return nil
+	}
+}
+
+// WithFailover sends the file to up to maxServers servers, in the order of the server selector,
+// until one succeeds. It only applies to sources that can be rewound (WithFile, WithBytes,
+// WithPath with a local file, or an io.Seeker) and without WithServerName.
+func WithFailover(maxServers int) UploadFileOption {
+	return func(params *UploadFileParams) error {
+		if maxServers < 1 {
+			return errors.New("maxServers must be at least 1")
+		}
+		params.Failover = &maxServers
+		return nil
+	}
+}
+
+// SkipMatch tells how WithSkipIfExists matches the file with the files of the folder,
+// the flags can be combined (ByName|ByMD5) to match on both.
+type SkipMatch int
+
+const (
+	// ByName matches the files with the same name
+	ByName SkipMatch = 1 << iota
+	// ByMD5 matches the files with the same md5 (and size), it needs a source that can be
+	// rewound as the file is read once to compute its md5
+	ByMD5
+)
+
+// WithSkipIfExists looks for the file in the folder given by WithFolderId before uploading it,
+// when it is found its info is returned with Existing set instead of uploading it again.
+// It fails without WithFolderId, ByMD5 with WithEncryption and ByName with WithEncryptedFileName,
+// an encrypted upload has a new md5 and encrypted name every time.
+func WithSkipIfExists(match SkipMatch) UploadFileOption {
+	return func(params *UploadFileParams) error {
+		if match&(ByName|ByMD5) == 0 {
+			return errors.New("match must be ByName, ByMD5 or both")
+		}
+		params.SkipIfExists = match
+		return nil
+	}
+}
+
+// WithEncryption encrypts the file with the encryption key of keys before it is sent, see the
+// encryption package. The md5 and sha256 of the upload are the ones of the encrypted data.
+func WithEncryption(keys encryption.KeyProvider) UploadFileOption {
+	return func(params *UploadFileParams) error {
+		if keys == nil {
+			return errors.New("keys is nil")
+		}
+		params.Encryption = keys
+		return nil
+	}
+}
+
+// WithEncryptedFileName also encrypts the file name, it needs WithEncryption.
+func WithEncryptedFileName() UploadFileOption {
+	return func(params *UploadFileParams) error {
+		params.EncryptFileName = true
+		return nil
+	}
+}
+
+// WithUploadDescription sets the description of the folder of the file once it is uploaded,
+// like the other WithUpload attribute options. Without WithFolderId it is the folder created
+// for the upload. When one fails the upload returns an *entity.PostUploadError with the uploaded file.
+func WithUploadDescription(description string) UploadFileOption {
+	return withUploadAttribute(WithDescription(description))
+}
+
+func WithUploadTags(tags []string) UploadFileOption {
+	return withUploadAttribute(WithTags(tags))
+}
+
+func WithUploadPublic(public bool) UploadFileOption {
+	return withUploadAttribute(WithPublic(public))
+}
+
+func WithUploadExpiry(expiry time.Time) UploadFileOption {
+	return withUploadAttribute(WithExpiry(expiry))
+}
+
+func WithUploadPassword(password string) UploadFileOption {
+	return withUploadAttribute(WithPassword(password))
+}
+
+func withUploadAttribute(option UpdateContentOption) UploadFileOption {
+	return func(params *UploadFileParams) error {
+		params.Attributes = append(params.Attributes, option)
+		return nil
+	}
+}
+
+// WithDeleteOnChecksumMismatch deletes the remote file when its md5 differs from
+// the md5 of the data sent, the upload then fails with an *entity.ChecksumError.
+func WithDeleteOnChecksumMismatch() UploadFileOption {
+	return func(params *UploadFileParams) error {
+		params.DeleteOnMismatch = true
+		return nil
+	}
+}
+
 func WithFileName(fileName string) UploadFileOption {
 	return func(params *UploadFileParams) error {
 		params.FileName = &fileName
@@ -0,0 +1,124 @@
+package gofile_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dvwzj/gofile"
+	"github.com/dvwzj/gofile/domain/api"
+	"github.com/dvwzj/gofile/entity"
+	"github.com/dvwzj/gofile/gofiletest"
+	"github.com/dvwzj/gofile/params"
+)
+
+var testRetryPolicy = api.RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     5 * time.Millisecond,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+func TestRetryPolicy(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	account := server.NewAccount(entity.AccountTierPremium)
+	client, err := server.NewClient(gofile.WithToken(account.Token), gofile.WithRetryPolicy(testRetryPolicy))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	server.FailNext(http.MethodGet, "/servers", http.StatusBadGateway, 2)
+	if _, err := client.GetServers(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := server.Requests(http.MethodGet, "/servers"); n != 3 {
+		t.Fatalf("unexpected requests: %d", n)
+	}
+
+	server.FailNext(http.MethodGet, "/accounts/getid", http.StatusServiceUnavailable, 3)
+	var apiErr *entity.APIError
+	if _, err := client.GetAccountId(); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	server.FailNext(http.MethodPost, "/store/store1/contents/uploadfile", 0, 1)
+	uploadedFile, err := client.UploadFile(params.WithBytes([]byte("retried"), "retried.txt"), params.WithServerName("store1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := server.FileData(uploadedFile.FileId); !bytes.Equal(data, []byte("retried")) {
+		t.Fatalf("unexpected file data: %q", data)
+	}
+
+	server.FailNext(http.MethodPost, "/store/store1/contents/uploadfile", 0, 1)
+	_, err = client.UploadFile(params.WithReader(bytes.NewBufferString("stream"), "stream.txt"), params.WithServerName("store1"))
+	if err == nil {
+		t.Fatalf("unexpected nil error")
+	}
+	if n := server.Requests(http.MethodPost, "/store/store1/contents/uploadfile"); n != 3 {
+		t.Fatalf("unexpected requests: %d", n)
+	}
+
+	copyPath := "/contents/" + uploadedFile.FileId + "/copy"
+	server.FailNext(http.MethodPost, copyPath, http.StatusBadGateway, 1)
+	if err := client.CopyContent(account.RootFolder, uploadedFile.FileId); err == nil {
+		t.Fatalf("unexpected nil error")
+	}
+	server.FailNext(http.MethodPost, copyPath, http.StatusTooManyRequests, 1)
+	if err := client.CopyContent(account.RootFolder, uploadedFile.FileId); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := server.Requests(http.MethodPost, copyPath); n != 3 {
+		t.Fatalf("unexpected requests: %d", n)
+	}
+
+	policy := testRetryPolicy
+	policy.RetryNonIdempotent = true
+	client, err = server.NewClient(gofile.WithToken(account.Token), gofile.WithRetryPolicy(policy))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	server.FailNext(http.MethodPost, copyPath, http.StatusBadGateway, 1)
+	if err := client.CopyContent(account.RootFolder, uploadedFile.FileId); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRetryPolicyContext(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	policy := testRetryPolicy
+	policy.InitialBackoff = time.Hour
+	policy.MaxBackoff = time.Hour
+	client, err := server.NewClient(gofile.WithRetryPolicy(policy))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	server.FailNext(http.MethodGet, "/servers", http.StatusBadGateway, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.GetServersContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := api.RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second, Multiplier: 2}
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}
+	for i, backoff := range expected {
+		if got := policy.Backoff(i + 1); got != backoff {
+			t.Fatalf("unexpected backoff for attempt %d: %v", i+1, got)
+		}
+	}
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := policy.Backoff(1); got < 500*time.Millisecond || got > 1500*time.Millisecond {
+			t.Fatalf("unexpected backoff with jitter: %v", got)
+		}
+	}
+}
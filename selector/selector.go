@@ -0,0 +1,92 @@
+package selector
+
+import (
+	"math/rand"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/dvwzj/gofile/entity"
+)
+
+// Candidate is an upload server that answered its probe.
+type Candidate struct {
+	Server  entity.Server
+	Latency time.Duration
+}
+
+type ServerSelector interface {
+	// Select returns the candidates in order of preference, the first one is used.
+	Select(candidates []Candidate) []Candidate
+}
+
+type SelectorFunc func(candidates []Candidate) []Candidate
+
+func (f SelectorFunc) Select(candidates []Candidate) []Candidate {
+	return f(candidates)
+}
+
+// LowestLatency prefers the servers that answered their probe the fastest.
+func LowestLatency() ServerSelector {
+	return SelectorFunc(func(candidates []Candidate) []Candidate {
+		sorted := append([]Candidate{}, candidates...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].Latency < sorted[j].Latency
+		})
+		return sorted
+	})
+}
+
+// PreferZone prefers the servers of the given zones (e.g. "eu", "na"), in the given order,
+// ties and the other servers are ordered by latency.
+func PreferZone(zones ...string) ServerSelector {
+	rank := map[string]int{}
+	for i, zone := range zones {
+		if _, ok := rank[zone]; !ok {
+			rank[zone] = i
+		}
+	}
+	zoneRank := func(zone string) int {
+		if r, ok := rank[zone]; ok {
+			return r
+		}
+		return len(zones)
+	}
+	return SelectorFunc(func(candidates []Candidate) []Candidate {
+		sorted := append([]Candidate{}, candidates...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			ri, rj := zoneRank(sorted[i].Server.Zone), zoneRank(sorted[j].Server.Zone)
+			if ri != rj {
+				return ri < rj
+			}
+			return sorted[i].Latency < sorted[j].Latency
+		})
+		return sorted
+	})
+}
+
+// RoundRobin starts each selection at the next server by name.
+func RoundRobin() ServerSelector {
+	next := atomic.Uint64{}
+	return SelectorFunc(func(candidates []Candidate) []Candidate {
+		if len(candidates) == 0 {
+			return nil
+		}
+		sorted := append([]Candidate{}, candidates...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].Server.Name < sorted[j].Server.Name
+		})
+		start := int((next.Add(1) - 1) % uint64(len(sorted)))
+		return append(append([]Candidate{}, sorted[start:]...), sorted[:start]...)
+	})
+}
+
+func Random() ServerSelector {
+	return SelectorFunc(func(candidates []Candidate) []Candidate {
+		shuffled := append([]Candidate{}, candidates...)
+		rand.Shuffle(len(shuffled), func(i, j int) {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		})
+		return shuffled
+	})
+}
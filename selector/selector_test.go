@@ -0,0 +1,77 @@
+package selector_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dvwzj/gofile/entity"
+	"github.com/dvwzj/gofile/selector"
+)
+
+var candidates = []selector.Candidate{
+	{Server: entity.Server{Name: "store3", Zone: "na"}, Latency: 30 * time.Millisecond},
+	{Server: entity.Server{Name: "store1", Zone: "eu"}, Latency: 50 * time.Millisecond},
+	{Server: entity.Server{Name: "store2", Zone: "na"}, Latency: 10 * time.Millisecond},
+	{Server: entity.Server{Name: "store4", Zone: "eu"}, Latency: 20 * time.Millisecond},
+}
+
+func names(candidates []selector.Candidate) []string {
+	result := []string{}
+	for _, candidate := range candidates {
+		result = append(result, candidate.Server.Name)
+	}
+	return result
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestLowestLatency(t *testing.T) {
+	got := names(selector.LowestLatency().Select(candidates))
+	if expected := []string{"store2", "store4", "store3", "store1"}; !equal(got, expected) {
+		t.Fatalf("unexpected order: %v", got)
+	}
+}
+
+func TestPreferZone(t *testing.T) {
+	got := names(selector.PreferZone("eu").Select(candidates))
+	if expected := []string{"store4", "store1", "store2", "store3"}; !equal(got, expected) {
+		t.Fatalf("unexpected order: %v", got)
+	}
+	got = names(selector.PreferZone("ap").Select(candidates))
+	if expected := []string{"store2", "store4", "store3", "store1"}; !equal(got, expected) {
+		t.Fatalf("unexpected order: %v", got)
+	}
+}
+
+func TestRoundRobin(t *testing.T) {
+	roundRobin := selector.RoundRobin()
+	for _, first := range []string{"store1", "store2", "store3", "store4", "store1"} {
+		if got := roundRobin.Select(candidates); got[0].Server.Name != first || len(got) != len(candidates) {
+			t.Fatalf("unexpected order: %v", names(got))
+		}
+	}
+}
+
+func TestRandom(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 200; i++ {
+		got := selector.Random().Select(candidates)
+		if len(got) != len(candidates) {
+			t.Fatalf("unexpected candidates: %v", names(got))
+		}
+		seen[got[0].Server.Name] = true
+	}
+	if len(seen) != len(candidates) {
+		t.Fatalf("random selection is not random: %v", seen)
+	}
+}
@@ -1,6 +1,12 @@
 package services
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+
 	"github.com/dvwzj/gofile/domain/api"
 	"github.com/dvwzj/gofile/entity"
 	"github.com/dvwzj/gofile/params"
@@ -9,73 +15,133 @@ import (
 
 type Service interface {
 	HttpClient() *resty.Client
+	API() api.API
+	// Health of the upload servers, from the probes and uploads made so far
+	ServerHealth() []entity.ServerHealth
 	// GET
 	// https://api.gofile.io/servers
 	GetServers() (*entity.Servers, error)
+	GetServersContext(ctx context.Context) (*entity.Servers, error)
 
 	// POST
 	// https://{server}.gofile.io/contents/uploadfile
 	UploadFile(file params.UploadFile, options ...params.UploadFileOption) (*entity.UploadedFile, error)
+	UploadFileContext(ctx context.Context, file params.UploadFile, options ...params.UploadFileOption) (*entity.UploadedFile, error)
+
+	// Uploads files concurrently, see UploadFilesContext
+	UploadFiles(files []params.UploadFile, options ...params.UploadBatchOption) ([]entity.UploadResult, error)
+	UploadFilesContext(ctx context.Context, files []params.UploadFile, options ...params.UploadBatchOption) ([]entity.UploadResult, error)
+
+	// Mirrors a local directory tree into folders, see UploadDirContext
+	UploadDir(localPath, parentFolderId string, options ...params.UploadBatchOption) (*entity.UploadedDir, error)
+	UploadDirContext(ctx context.Context, localPath, parentFolderId string, options ...params.UploadBatchOption) (*entity.UploadedDir, error)
+	UploadFS(fsys fs.FS, root, parentFolderId string, options ...params.UploadBatchOption) (*entity.UploadedDir, error)
+	UploadFSContext(ctx context.Context, fsys fs.FS, root, parentFolderId string, options ...params.UploadBatchOption) (*entity.UploadedDir, error)
+
+	// Streams a tar.gz or zip of a local directory as a single file, see UploadArchiveContext
+	UploadArchive(dir string, format params.ArchiveFormat, options ...params.UploadArchiveOption) (*entity.UploadedFile, error)
+	UploadArchiveContext(ctx context.Context, dir string, format params.ArchiveFormat, options ...params.UploadArchiveOption) (*entity.UploadedFile, error)
+
+	// Downloads a file with the account token, see DownloadFileContext and DownloadToPathContext
+	DownloadFile(file params.DownloadFile, dst io.Writer, options ...params.DownloadOption) (*entity.ChildContentFile, error)
+	DownloadFileContext(ctx context.Context, file params.DownloadFile, dst io.Writer, options ...params.DownloadOption) (*entity.ChildContentFile, error)
+	DownloadToPath(file params.DownloadFile, path string, options ...params.DownloadOption) (*entity.ChildContentFile, error)
+	DownloadToPathContext(ctx context.Context, file params.DownloadFile, path string, options ...params.DownloadOption) (*entity.ChildContentFile, error)
+
+	// Downloads a folder and its subfolders, see DownloadFolderContext
+	DownloadFolder(folderId, localDir string, options ...params.DownloadOption) (*entity.DownloadedDir, error)
+	DownloadFolderContext(ctx context.Context, folderId, localDir string, options ...params.DownloadOption) (*entity.DownloadedDir, error)
+
+	// Uploads a file in parts with a manifest, see UploadLargeContext and DownloadLargeContext
+	UploadLarge(file params.UploadFile, options ...params.UploadBatchOption) (*entity.LargeUpload, error)
+	UploadLargeContext(ctx context.Context, file params.UploadFile, options ...params.UploadBatchOption) (*entity.LargeUpload, error)
+	DownloadLarge(folderId string, dst io.Writer, options ...params.DownloadOption) (*entity.LargeManifest, error)
+	DownloadLargeContext(ctx context.Context, folderId string, dst io.Writer, options ...params.DownloadOption) (*entity.LargeManifest, error)
 
 	// POST
 	// https://api.gofile.io/contents/createFolder
 	CreateFolder(parentFolderId string, options ...params.CreateFolderOption) (*entity.CreatedFolder, error)
+	CreateFolderContext(ctx context.Context, parentFolderId string, options ...params.CreateFolderOption) (*entity.CreatedFolder, error)
 
 	// PUT
 	// https://api.gofile.io/contents/{contentId}/update
 	UpdateContent(contentId string, option params.UpdateContentOption) error
+	UpdateContentContext(ctx context.Context, contentId string, option params.UpdateContentOption) error
 
 	// DELETE
 	// https://api.gofile.io/contents
 	DeleteContents(contentsId []string) (*map[string]entity.EmptyDataResponse, error)
+	DeleteContentsContext(ctx context.Context, contentsId []string) (*map[string]entity.EmptyDataResponse, error)
 
 	// DELETE
 	// https://api.gofile.io/contents/{contentId}
 	DeleteContent(contentId string) error
+	DeleteContentContext(ctx context.Context, contentId string) error
 
 	// GET
 	// https://api.gofile.io/contents/{contentId}
-	GetContent(contentId string) (*entity.Content, error)
+	GetContent(contentId string, options ...params.GetContentOption) (*entity.Content, error)
+	GetContentContext(ctx context.Context, contentId string, options ...params.GetContentOption) (*entity.Content, error)
+	// Gets the content of a share url or a content code, see ResolveShareURLContext
+	ResolveShareURL(shareURL string, options ...params.GetContentOption) (*entity.Content, error)
+	ResolveShareURLContext(ctx context.Context, shareURL string, options ...params.GetContentOption) (*entity.Content, error)
+	GetContentByCode(code string, options ...params.GetContentOption) (*entity.Content, error)
+	GetContentByCodeContext(ctx context.Context, code string, options ...params.GetContentOption) (*entity.Content, error)
 
 	// POST
 	// https://api.gofile.io/contents/{contentId}/directlinks
 	CreateDirectLink(contentId string, directLink entity.DirectLink) (*entity.DirectLink, error)
+	CreateDirectLinkContext(ctx context.Context, contentId string, directLink entity.DirectLink) (*entity.DirectLink, error)
 
 	// PUT
 	// https://api.gofile.io/contents/{contentId}/directlinks/{directLinkId}
 	UpdateDirectLink(contentId, directLinkId string, directLink entity.DirectLink) (*entity.DirectLink, error)
+	UpdateDirectLinkContext(ctx context.Context, contentId, directLinkId string, directLink entity.DirectLink) (*entity.DirectLink, error)
 
 	// DELETE
 	// https://api.gofile.io/contents/{contentId}/directlinks/{directLinkId}
 	DeleteDirectLink(contentId, directLinkId string) error
+	DeleteDirectLinkContext(ctx context.Context, contentId, directLinkId string) error
 
 	// POST
 	// https://api.gofile.io/contents/copy
 	CopyContents(folderId string, contentsId []string) error
+	CopyContentsContext(ctx context.Context, folderId string, contentsId []string) error
 
 	// POST
 	// https://api.gofile.io/contents/{contentId}/copy
 	CopyContent(folderId, contentId string) error
+	CopyContentContext(ctx context.Context, folderId, contentId string) error
 
 	// PUT
 	// https://api.gofile.io/contents/move
 	MoveContents(folderId string, contentsId []string) error
+	MoveContentsContext(ctx context.Context, folderId string, contentsId []string) error
 
 	// PUT
 	// https://api.gofile.io/contents/{contentId}/move
 	MoveContent(folderId string, contentId string) error
+	MoveContentContext(ctx context.Context, folderId string, contentId string) error
 
 	// GET
 	// https://api.gofile.io/accounts/getid
 	GetAccountId() (string, error)
+	GetAccountIdContext(ctx context.Context) (string, error)
 
 	// GET
 	// https://api.gofile.io/accounts/{accountId}
 	GetAccount() (*entity.Account, error)
+	GetAccountContext(ctx context.Context) (*entity.Account, error)
+
+	// POST
+	// https://api.gofile.io/accounts
+	CreateAccount(email string) (*entity.CreatedAccount, error)
+	CreateAccountContext(ctx context.Context, email string) (*entity.CreatedAccount, error)
 
 	// POST
 	// https://api.gofile.io/accounts/{accountId}/resettoken
 	ResetAccountToken() error
+	ResetAccountTokenContext(ctx context.Context) error
 }
 
 type API struct {
@@ -83,11 +149,23 @@ type API struct {
 }
 
 func (a API) HttpClient() *resty.Client {
-	return a.Repository.(*api.Domain).HttpClient()
+	return a.API().HttpClient()
+}
+
+func (a API) API() api.API {
+	return a.Repository.(api.API)
+}
+
+func (a API) ServerHealth() []entity.ServerHealth {
+	return a.API().ServerHealth()
 }
 
 func (a API) GetServers() (*entity.Servers, error) {
-	resp, err := a.Repository.GetServers()
+	return a.GetServersContext(context.Background())
+}
+
+func (a API) GetServersContext(ctx context.Context) (*entity.Servers, error) {
+	resp, err := a.Repository.GetServersContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -95,7 +173,11 @@ func (a API) GetServers() (*entity.Servers, error) {
 }
 
 func (a API) UploadFile(file params.UploadFile, options ...params.UploadFileOption) (*entity.UploadedFile, error) {
-	resp, err := a.Repository.UploadFile(file, options...)
+	return a.UploadFileContext(context.Background(), file, options...)
+}
+
+func (a API) UploadFileContext(ctx context.Context, file params.UploadFile, options ...params.UploadFileOption) (*entity.UploadedFile, error) {
+	resp, err := a.Repository.UploadFileContext(ctx, file, options...)
 	if err != nil {
 		return nil, err
 	}
@@ -103,7 +185,11 @@ func (a API) UploadFile(file params.UploadFile, options ...params.UploadFileOpti
 }
 
 func (a API) CreateFolder(parentFolderId string, options ...params.CreateFolderOption) (*entity.CreatedFolder, error) {
-	resp, err := a.Repository.CreateFolder(parentFolderId, options...)
+	return a.CreateFolderContext(context.Background(), parentFolderId, options...)
+}
+
+func (a API) CreateFolderContext(ctx context.Context, parentFolderId string, options ...params.CreateFolderOption) (*entity.CreatedFolder, error) {
+	resp, err := a.Repository.CreateFolderContext(ctx, parentFolderId, options...)
 	if err != nil {
 		return nil, err
 	}
@@ -111,7 +197,11 @@ func (a API) CreateFolder(parentFolderId string, options ...params.CreateFolderO
 }
 
 func (a API) UpdateContent(contentId string, option params.UpdateContentOption) error {
-	_, err := a.Repository.UpdateContent(contentId, option)
+	return a.UpdateContentContext(context.Background(), contentId, option)
+}
+
+func (a API) UpdateContentContext(ctx context.Context, contentId string, option params.UpdateContentOption) error {
+	_, err := a.Repository.UpdateContentContext(ctx, contentId, option)
 	if err != nil {
 		return err
 	}
@@ -119,39 +209,75 @@ func (a API) UpdateContent(contentId string, option params.UpdateContentOption)
 }
 
 func (a API) DeleteContents(contentsId []string) (*map[string]entity.EmptyDataResponse, error) {
-	resp, err := a.Repository.DeleteContents(contentsId)
+	return a.DeleteContentsContext(context.Background(), contentsId)
+}
+
+func (a API) DeleteContentsContext(ctx context.Context, contentsId []string) (*map[string]entity.EmptyDataResponse, error) {
+	resp, err := a.Repository.DeleteContentsContext(ctx, contentsId)
 	if err != nil {
 		return nil, err
 	}
-	for _, v := range resp.Data {
+	for contentId, v := range resp.Data {
 		if v.Status != "ok" {
-			return nil, entity.ErrorResponseStatus(v.Status)
+			return nil, &entity.APIError{
+				StatusCode: http.StatusOK,
+				Method:     http.MethodDelete,
+				Endpoint:   "/contents",
+				Status:     v.Status,
+				ContentId:  contentId,
+			}
 		}
 	}
 	return &resp.Data, nil
 }
 
 func (a API) DeleteContent(contentId string) error {
-	_, err := a.Repository.DeleteContent(contentId)
+	return a.DeleteContentContext(context.Background(), contentId)
+}
+
+func (a API) DeleteContentContext(ctx context.Context, contentId string) error {
+	_, err := a.Repository.DeleteContentContext(ctx, contentId)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (a API) GetContent(contentId string) (*entity.Content, error) {
-	resp, err := a.Repository.GetContent(contentId)
+func (a API) GetContent(contentId string, options ...params.GetContentOption) (*entity.Content, error) {
+	return a.GetContentContext(context.Background(), contentId, options...)
+}
+
+func (a API) GetContentContext(ctx context.Context, contentId string, options ...params.GetContentOption) (*entity.Content, error) {
+	resp, err := a.Repository.GetContentContext(ctx, contentId, options...)
 	if err != nil {
 		return nil, err
 	}
+	// a content protected by a password is answered "ok", its data tells whether the password is right
+	if status := resp.Data.PasswordStatus; status != "" && status != "passwordOk" {
+		err := entity.ErrWrongPassword
+		if status == "passwordRequired" {
+			err = entity.ErrPasswordRequired
+		}
+		return nil, fmt.Errorf("%w: content %s", err, contentId)
+	}
 	if resp.Data.Id == "" && !resp.Data.Public {
-		return nil, entity.ErrorResponseStatus("error-privateContent")
+		return nil, &entity.APIError{
+			StatusCode: http.StatusOK,
+			Method:     http.MethodGet,
+			Endpoint:   "/contents/" + contentId,
+			Status:     entity.ErrPrivateContent.Error(),
+			ContentId:  contentId,
+		}
 	}
 	return &resp.Data, nil
 }
 
 func (a API) CreateDirectLink(contentId string, directLink entity.DirectLink) (*entity.DirectLink, error) {
-	resp, err := a.Repository.CreateDirectLink(contentId, directLink)
+	return a.CreateDirectLinkContext(context.Background(), contentId, directLink)
+}
+
+func (a API) CreateDirectLinkContext(ctx context.Context, contentId string, directLink entity.DirectLink) (*entity.DirectLink, error) {
+	resp, err := a.Repository.CreateDirectLinkContext(ctx, contentId, directLink)
 	if err != nil {
 		return nil, err
 	}
@@ -159,7 +285,11 @@ func (a API) CreateDirectLink(contentId string, directLink entity.DirectLink) (*
 }
 
 func (a API) UpdateDirectLink(contentId, directLinkId string, directLink entity.DirectLink) (*entity.DirectLink, error) {
-	resp, err := a.Repository.UpdateDirectLink(contentId, directLinkId, directLink)
+	return a.UpdateDirectLinkContext(context.Background(), contentId, directLinkId, directLink)
+}
+
+func (a API) UpdateDirectLinkContext(ctx context.Context, contentId, directLinkId string, directLink entity.DirectLink) (*entity.DirectLink, error) {
+	resp, err := a.Repository.UpdateDirectLinkContext(ctx, contentId, directLinkId, directLink)
 	if err != nil {
 		return nil, err
 	}
@@ -167,7 +297,11 @@ func (a API) UpdateDirectLink(contentId, directLinkId string, directLink entity.
 }
 
 func (a API) DeleteDirectLink(contentId, directLinkId string) error {
-	_, err := a.Repository.DeleteDirectLink(contentId, directLinkId)
+	return a.DeleteDirectLinkContext(context.Background(), contentId, directLinkId)
+}
+
+func (a API) DeleteDirectLinkContext(ctx context.Context, contentId, directLinkId string) error {
+	_, err := a.Repository.DeleteDirectLinkContext(ctx, contentId, directLinkId)
 	if err != nil {
 		return err
 	}
@@ -175,7 +309,11 @@ func (a API) DeleteDirectLink(contentId, directLinkId string) error {
 }
 
 func (a API) CopyContents(folderId string, contentsId []string) error {
-	_, err := a.Repository.CopyContents(folderId, contentsId)
+	return a.CopyContentsContext(context.Background(), folderId, contentsId)
+}
+
+func (a API) CopyContentsContext(ctx context.Context, folderId string, contentsId []string) error {
+	_, err := a.Repository.CopyContentsContext(ctx, folderId, contentsId)
 	if err != nil {
 		return err
 	}
@@ -183,7 +321,11 @@ func (a API) CopyContents(folderId string, contentsId []string) error {
 }
 
 func (a API) CopyContent(folderId, contentId string) error {
-	_, err := a.Repository.CopyContent(folderId, contentId)
+	return a.CopyContentContext(context.Background(), folderId, contentId)
+}
+
+func (a API) CopyContentContext(ctx context.Context, folderId, contentId string) error {
+	_, err := a.Repository.CopyContentContext(ctx, folderId, contentId)
 	if err != nil {
 		return err
 	}
@@ -191,7 +333,11 @@ func (a API) CopyContent(folderId, contentId string) error {
 }
 
 func (a API) MoveContents(folderId string, contentsId []string) error {
-	_, err := a.Repository.MoveContents(folderId, contentsId)
+	return a.MoveContentsContext(context.Background(), folderId, contentsId)
+}
+
+func (a API) MoveContentsContext(ctx context.Context, folderId string, contentsId []string) error {
+	_, err := a.Repository.MoveContentsContext(ctx, folderId, contentsId)
 	if err != nil {
 		return err
 	}
@@ -199,7 +345,11 @@ func (a API) MoveContents(folderId string, contentsId []string) error {
 }
 
 func (a API) MoveContent(folderId string, contentId string) error {
-	_, err := a.Repository.MoveContent(folderId, contentId)
+	return a.MoveContentContext(context.Background(), folderId, contentId)
+}
+
+func (a API) MoveContentContext(ctx context.Context, folderId string, contentId string) error {
+	_, err := a.Repository.MoveContentContext(ctx, folderId, contentId)
 	if err != nil {
 		return err
 	}
@@ -207,7 +357,11 @@ func (a API) MoveContent(folderId string, contentId string) error {
 }
 
 func (a API) GetAccountId() (string, error) {
-	resp, err := a.Repository.GetAccountId()
+	return a.GetAccountIdContext(context.Background())
+}
+
+func (a API) GetAccountIdContext(ctx context.Context) (string, error) {
+	resp, err := a.Repository.GetAccountIdContext(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -215,11 +369,27 @@ func (a API) GetAccountId() (string, error) {
 }
 
 func (a API) GetAccount() (*entity.Account, error) {
-	accountId, err := a.GetAccountId()
+	return a.GetAccountContext(context.Background())
+}
+
+func (a API) GetAccountContext(ctx context.Context) (*entity.Account, error) {
+	accountId, err := a.GetAccountIdContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := a.Repository.GetAccount(accountId)
+	resp, err := a.Repository.GetAccountContext(ctx, accountId)
+	if err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+func (a API) CreateAccount(email string) (*entity.CreatedAccount, error) {
+	return a.CreateAccountContext(context.Background(), email)
+}
+
+func (a API) CreateAccountContext(ctx context.Context, email string) (*entity.CreatedAccount, error) {
+	resp, err := a.Repository.CreateAccountContext(ctx, email)
 	if err != nil {
 		return nil, err
 	}
@@ -227,11 +397,15 @@ func (a API) GetAccount() (*entity.Account, error) {
 }
 
 func (a API) ResetAccountToken() error {
-	accountId, err := a.GetAccountId()
+	return a.ResetAccountTokenContext(context.Background())
+}
+
+func (a API) ResetAccountTokenContext(ctx context.Context) error {
+	accountId, err := a.GetAccountIdContext(ctx)
 	if err != nil {
 		return err
 	}
-	_, err = a.Repository.ResetAccountToken(accountId)
+	_, err = a.Repository.ResetAccountTokenContext(ctx, accountId)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,371 @@
+package services
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/dvwzj/gofile/encryption"
+	"github.com/dvwzj/gofile/entity"
+	"github.com/dvwzj/gofile/params"
+)
+
+func (a API) DownloadFile(file params.DownloadFile, dst io.Writer, options ...params.DownloadOption) (*entity.ChildContentFile, error) {
+	return a.DownloadFileContext(context.Background(), file, dst, options...)
+}
+
+// DownloadFileContext writes file to dst, an interrupted transfer is resumed with a Range request.
+// The md5 listed for the file is checked once dst was written, a mismatch fails with an *entity.ChecksumError.
+func (a API) DownloadFileContext(ctx context.Context, file params.DownloadFile, dst io.Writer, options ...params.DownloadOption) (*entity.ChildContentFile, error) {
+	downloadParams, err := newDownloadParams(options)
+	if err != nil {
+		return nil, err
+	}
+	childFile, err := a.resolveFile(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	r := &resumingReader{ctx: ctx, api: a, link: childFile.Link, attempts: downloadParams.ResumeAttempts}
+	defer r.Close()
+	sum := md5.New()
+	var src io.Reader = io.TeeReader(r, sum)
+	if downloadParams.Decryption != nil {
+		if src, err = encryption.NewDecryptReader(src, downloadParams.Decryption); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		return nil, err
+	}
+	if err := checkMD5(childFile, sum); err != nil {
+		return nil, err
+	}
+	return decryptName(childFile, downloadParams), nil
+}
+
+func (a API) DownloadToPath(file params.DownloadFile, path string, options ...params.DownloadOption) (*entity.ChildContentFile, error) {
+	return a.DownloadToPathContext(context.Background(), file, path, options...)
+}
+
+// DownloadToPathContext downloads file to path, or in the directory path under the name of the file.
+// The data is written to path + ".part" first, a later call resumes it with a Range request, and the
+// file is moved to path once its md5 is checked. A file that does not match its md5 is removed.
+func (a API) DownloadToPathContext(ctx context.Context, file params.DownloadFile, path string, options ...params.DownloadOption) (*entity.ChildContentFile, error) {
+	downloadParams, err := newDownloadParams(options)
+	if err != nil {
+		return nil, err
+	}
+	childFile, err := a.resolveFile(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	childFile = decryptName(childFile, downloadParams)
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, filepath.Base(childFile.Name))
+	}
+	partPath := path + ".part"
+	part, err := os.OpenFile(partPath, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { part.Close() }()
+	sum := md5.New()
+	offset, err := io.Copy(sum, part)
+	if err != nil {
+		return nil, err
+	}
+	if offset > int64(childFile.Size) {
+		if offset, err = restartPart(part, sum); err != nil {
+			return nil, err
+		}
+	}
+	// a part file left by a single stream is resumed with a single stream
+	if downloadParams.Segments > 1 && offset == 0 && childFile.Size > 1 {
+		// the segments leave holes until they are all written, so they are written to another file
+		// that becomes the part file once complete, a part file is never a full size file with holes
+		segmentsPath := path + ".segments"
+		segments, err := os.OpenFile(segmentsPath, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		err = a.downloadSegments(ctx, childFile, segments, downloadParams)
+		if closeErr := segments.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(segmentsPath)
+			if !errors.Is(err, errRangeIgnored) {
+				part.Close()
+				os.Remove(partPath)
+				return nil, err
+			}
+		} else {
+			part.Close()
+			if err := os.Rename(segmentsPath, partPath); err != nil {
+				return nil, err
+			}
+			if part, err = os.OpenFile(partPath, os.O_RDWR, 0o644); err != nil {
+				return nil, err
+			}
+			if offset, err = io.Copy(sum, part); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if offset < int64(childFile.Size) || childFile.Size == 0 {
+		r := &resumingReader{ctx: ctx, api: a, link: childFile.Link, attempts: downloadParams.ResumeAttempts}
+		defer r.Close()
+		if err := r.open(offset); errors.Is(err, errRangeIgnored) {
+			// the server sends the whole file
+			if offset, err = restartPart(part, sum); err != nil {
+				return nil, err
+			}
+			err = r.open(offset)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.Copy(io.MultiWriter(part, sum), r); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkMD5(childFile, sum); err != nil {
+		part.Close()
+		os.Remove(partPath)
+		return nil, err
+	}
+	if downloadParams.Decryption == nil {
+		if err := part.Close(); err != nil {
+			return nil, err
+		}
+		return childFile, os.Rename(partPath, path)
+	}
+	if _, err := part.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	decrypted, err := encryption.NewDecryptReader(part, downloadParams.Decryption)
+	if err != nil {
+		return nil, err
+	}
+	tmpPath := path + ".tmp"
+	if err := writeFile(tmpPath, decrypted); err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	part.Close()
+	os.Remove(partPath)
+	return childFile, os.Rename(tmpPath, path)
+}
+
+func newDownloadParams(options []params.DownloadOption) (*params.DownloadParams, error) {
+	downloadParams := &params.DownloadParams{
+		Workers:        params.DefaultDownloadWorkers,
+		ResumeAttempts: params.DefaultResumeAttempts,
+	}
+	for _, option := range options {
+		if err := option(downloadParams); err != nil {
+			return nil, err
+		}
+	}
+	return downloadParams, nil
+}
+
+// resolveFile finds the link and the md5 of a file in the content of its folder.
+func (a API) resolveFile(ctx context.Context, file params.DownloadFile) (*entity.ChildContentFile, error) {
+	fileParams := &params.DownloadFileParams{}
+	if err := file(fileParams); err != nil {
+		return nil, err
+	}
+	if fileParams.File != nil {
+		childFile := *fileParams.File
+		return &childFile, nil
+	}
+	fileId := *fileParams.FileId
+	content, err := a.GetContentContext(ctx, fileId)
+	if err != nil {
+		return nil, err
+	}
+	if content.Type != entity.ContentTypeFile {
+		return nil, fmt.Errorf("%w: %s is a %s", entity.ErrorType, fileId, content.Type)
+	}
+	folder, err := a.GetContentContext(ctx, content.ParentFolder)
+	if err != nil {
+		return nil, err
+	}
+	for _, childFile := range folder.Children.Files() {
+		if childFile.Id == fileId {
+			return &childFile, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: file %s", entity.ErrorNotFound, fileId)
+}
+
+func decryptName(childFile *entity.ChildContentFile, downloadParams *params.DownloadParams) *entity.ChildContentFile {
+	if downloadParams.Decryption == nil {
+		return childFile
+	}
+	if name, err := encryption.DecryptFileName(childFile.Name, downloadParams.Decryption); err == nil {
+		childFile.Name = name
+	}
+	return childFile
+}
+
+func checkMD5(childFile *entity.ChildContentFile, sum hash.Hash) error {
+	actual := hex.EncodeToString(sum.Sum(nil))
+	if childFile.MD5 == "" || strings.EqualFold(childFile.MD5, actual) {
+		return nil
+	}
+	return &entity.ChecksumError{
+		FileId:   childFile.Id,
+		Server:   childFile.ServerSelected,
+		Expected: childFile.MD5,
+		Actual:   actual,
+	}
+}
+
+func restartPart(part *os.File, sum hash.Hash) (int64, error) {
+	sum.Reset()
+	if err := part.Truncate(0); err != nil {
+		return 0, err
+	}
+	return part.Seek(0, io.SeekStart)
+}
+
+func writeFile(path string, r io.Reader) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+var errRangeIgnored = errors.New("the server ignored the range of the request")
+
+// downloadSegments writes childFile in file with downloadParams.Segments ranges fetched at the same time,
+// it fails with errRangeIgnored when the server answers the first range with the whole file.
+func (a API) downloadSegments(ctx context.Context, childFile *entity.ChildContentFile, file *os.File, downloadParams *params.DownloadParams) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	size := int64(childFile.Size)
+	segments := min(int64(downloadParams.Segments), size)
+	segmentSize := (size + segments - 1) / segments
+	readers := make([]*resumingReader, 0, segments)
+	for start := int64(0); start < size; start += segmentSize {
+		readers = append(readers, &resumingReader{
+			ctx:      ctx,
+			api:      a,
+			link:     childFile.Link,
+			attempts: downloadParams.ResumeAttempts,
+			offset:   start,
+			end:      min(start+segmentSize, size),
+		})
+	}
+	// the first range tells whether the server supports them before the others are sent
+	if err := readers[0].open(0); err != nil {
+		return err
+	}
+	if err := file.Truncate(size); err != nil {
+		readers[0].Close()
+		return err
+	}
+	errs := make([]error, len(readers))
+	wg := sync.WaitGroup{}
+	for i, r := range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer r.Close()
+			start, length := r.offset, r.end-r.offset
+			n, err := io.Copy(io.NewOffsetWriter(file, start), r)
+			if err == nil && n != length {
+				err = io.ErrUnexpectedEOF
+			}
+			if err != nil {
+				errs[i] = fmt.Errorf("segment %d-%d: %w", start, start+length-1, err)
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		// the segments canceled by the failure of another one are not reported
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return err
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// resumingReader reads a download link, up to end when it is not 0, a read that fails with
+// a transient error reopens the link from the offset reached, up to attempts times.
+type resumingReader struct {
+	ctx      context.Context
+	api      API
+	link     string
+	attempts int
+	offset   int64
+	end      int64
+	body     io.ReadCloser
+}
+
+func (r *resumingReader) open(offset int64) error {
+	length := int64(0)
+	if r.end > 0 {
+		length = r.end - offset
+	}
+	resp, err := r.api.API().OpenLinkRangeContext(r.ctx, r.link, offset, length)
+	if err != nil {
+		return err
+	}
+	if (offset > 0 || length > 0) && resp.StatusCode != http.StatusPartialContent {
+		resp.Body.Close()
+		return errRangeIgnored
+	}
+	r.offset = offset
+	r.body = resp.Body
+	return nil
+}
+
+func (r *resumingReader) Read(p []byte) (int, error) {
+	for {
+		if r.body == nil {
+			if err := r.open(r.offset); err != nil {
+				return 0, err
+			}
+		}
+		n, err := r.body.Read(p)
+		r.offset += int64(n)
+		if err == nil || err == io.EOF {
+			return n, err
+		}
+		r.body.Close()
+		r.body = nil
+		if r.attempts == 0 || !entity.IsRetryable(err) || r.ctx.Err() != nil {
+			return n, err
+		}
+		r.attempts--
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+func (r *resumingReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	return r.body.Close()
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dvwzj/gofile/entity"
+	"github.com/dvwzj/gofile/params"
+)
+
+func (a API) DownloadFolder(folderId, localDir string, options ...params.DownloadOption) (*entity.DownloadedDir, error) {
+	return a.DownloadFolderContext(context.Background(), folderId, localDir, options...)
+}
+
+// DownloadFolderContext mirrors the content of the folder folderId in localDir, the subfolders are
+// created as directories and the files are downloaded with a pool of workers (params.WithDownloadWorkers)
+// and get their CreateTime as modification time. A local file that already has the md5 of the remote
+// file is skipped. Of the contents of a folder with the same name, the first one by name then id is
+// written and the others fail. The result is returned with the errors of the folders and files that
+// failed, joined.
+func (a API) DownloadFolderContext(ctx context.Context, folderId, localDir string, options ...params.DownloadOption) (*entity.DownloadedDir, error) {
+	downloadParams, err := newDownloadParams(options)
+	if err != nil {
+		return nil, err
+	}
+	type job struct {
+		file   entity.ChildContentFile
+		result *entity.DownloadedFile
+	}
+	jobs := []job{}
+	var walk func(dir *entity.DownloadedDir) error
+	walk = func(dir *entity.DownloadedDir) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		content, err := a.GetContentContext(ctx, dir.FolderId)
+		if err != nil {
+			dir.Err = err
+			return nil
+		}
+		if content.Type != entity.ContentTypeFolder {
+			dir.Err = fmt.Errorf("%w: %s is a %s", entity.ErrorType, dir.FolderId, content.Type)
+			return nil
+		}
+		if err := os.MkdirAll(dir.LocalPath, 0o755); err != nil {
+			dir.Err = err
+			return nil
+		}
+		used := map[string]bool{}
+		files := content.Children.Files()
+		sort.Slice(files, func(i, j int) bool {
+			if files[i].Name != files[j].Name {
+				return files[i].Name < files[j].Name
+			}
+			return files[i].Id < files[j].Id
+		})
+		dir.Files = make([]entity.DownloadedFile, len(files))
+		for i, file := range files {
+			dir.Files[i] = entity.DownloadedFile{FileId: file.Id, Size: file.Size, MD5: file.MD5}
+			name := decryptName(&entity.ChildContentFile{Name: file.Name}, downloadParams).Name
+			if err := checkLocalName(name, used); err != nil {
+				dir.Files[i].LocalPath = dir.LocalPath
+				dir.Files[i].Err = err
+				continue
+			}
+			dir.Files[i].LocalPath = filepath.Join(dir.LocalPath, name)
+			jobs = append(jobs, job{file: file, result: &dir.Files[i]})
+		}
+		folders := content.Children.Folders()
+		sort.Slice(folders, func(i, j int) bool {
+			if folders[i].Name != folders[j].Name {
+				return folders[i].Name < folders[j].Name
+			}
+			return folders[i].Id < folders[j].Id
+		})
+		for _, folder := range folders {
+			sub := &entity.DownloadedDir{FolderId: folder.Id, LocalPath: filepath.Join(dir.LocalPath, folder.Name)}
+			dir.Folders = append(dir.Folders, sub)
+			if err := checkLocalName(folder.Name, used); err != nil {
+				sub.LocalPath = dir.LocalPath
+				sub.Err = err
+				continue
+			}
+			if err := walk(sub); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	root := &entity.DownloadedDir{FolderId: folderId, LocalPath: localDir}
+	if err := walk(root); err != nil {
+		return root, err
+	}
+	if root.Err != nil {
+		return root, root.Err
+	}
+	queue := make(chan job)
+	wg := sync.WaitGroup{}
+	for i := 0; i < downloadParams.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				job.result.Skipped, job.result.Err = a.downloadFolderFile(ctx, job.file, job.result.LocalPath, options)
+			}
+		}()
+	}
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return root, err
+	}
+	return root, root.Errs()
+}
+
+// downloadFolderFile downloads file to localPath unless it is already there, and reports whether it was skipped.
+func (a API) downloadFolderFile(ctx context.Context, file entity.ChildContentFile, localPath string, options []params.DownloadOption) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	modTime := time.Unix(int64(file.CreateTime), 0)
+	if sameMD5(localPath, file) {
+		return true, os.Chtimes(localPath, modTime, modTime)
+	}
+	if _, err := a.DownloadToPathContext(ctx, params.WithChildFile(file), localPath, options...); err != nil {
+		return false, err
+	}
+	return false, os.Chtimes(localPath, modTime, modTime)
+}
+
+// sameMD5 reports whether the regular file localPath has the size and the md5 of file.
+func sameMD5(localPath string, file entity.ChildContentFile) bool {
+	info, err := os.Stat(localPath)
+	if err != nil || !info.Mode().IsRegular() || info.Size() != int64(file.Size) || file.MD5 == "" {
+		return false
+	}
+	f, err := os.Open(localPath)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	sum := md5.New()
+	if _, err := io.Copy(sum, f); err != nil {
+		return false
+	}
+	return strings.EqualFold(hex.EncodeToString(sum.Sum(nil)), file.MD5)
+}
+
+// checkLocalName rejects the remote names that would be written outside of their directory, and the
+// ones already used in it, then adds name to used.
+func checkLocalName(name string, used map[string]bool) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("%q is not a valid local name", name)
+	}
+	if used[name] {
+		return fmt.Errorf("%q is already the name of another content of the folder", name)
+	}
+	used[name] = true
+	return nil
+}
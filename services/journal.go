@@ -0,0 +1,121 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// journalEntry is a line of the journal of UploadDir, a folder created or a file uploaded.
+type journalEntry struct {
+	Type           string    `json:"type"`
+	Path           string    `json:"path"`
+	ParentFolderId string    `json:"parentFolderId,omitempty"`
+	FolderId       string    `json:"folderId"`
+	Code           string    `json:"code,omitempty"`
+	FileId         string    `json:"fileId,omitempty"`
+	Size           int64     `json:"size,omitempty"`
+	ModTime        time.Time `json:"modTime,omitempty"`
+	MD5            string    `json:"md5,omitempty"`
+}
+
+// journal records the work done by UploadDir in an append only file, one json entry per line,
+// so a rerun can skip it. A line cut by a crash is dropped when the journal is opened.
+type journal struct {
+	mu      sync.Mutex
+	f       *os.File
+	folders map[string]journalEntry
+	files   map[string]journalEntry
+}
+
+func openJournal(path string) (*journal, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	j := &journal{
+		f:       file,
+		folders: map[string]journalEntry{},
+		files:   map[string]journalEntry{},
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	// the entries are appended after the last complete line, not to the line cut by a crash
+	if end := bytes.LastIndexByte(data, '\n') + 1; end < len(data) {
+		if err := file.Truncate(int64(end)); err != nil {
+			file.Close()
+			return nil, err
+		}
+		data = data[:end]
+	}
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		entry := journalEntry{}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			continue
+		}
+		switch entry.Type {
+		case "folder":
+			j.folders[entry.Path] = entry
+		case "file":
+			j.files[entry.Path] = entry
+		}
+	}
+	return j, nil
+}
+
+// folder returns the folder created for path in parentFolderId by a previous run.
+func (j *journal) folder(path, parentFolderId string) (journalEntry, bool) {
+	if j == nil {
+		return journalEntry{}, false
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entry, ok := j.folders[path]
+	return entry, ok && entry.ParentFolderId == parentFolderId
+}
+
+// file returns the upload of path in folderId by a previous run, when the file did not change since.
+func (j *journal) file(path, folderId string, info os.FileInfo) (journalEntry, bool) {
+	if j == nil {
+		return journalEntry{}, false
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entry, ok := j.files[path]
+	return entry, ok && entry.FolderId == folderId && entry.Size == info.Size() && entry.ModTime.Equal(info.ModTime())
+}
+
+func (j *journal) record(entry journalEntry) error {
+	if j == nil {
+		return nil
+	}
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, err := j.f.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	switch entry.Type {
+	case "folder":
+		j.folders[entry.Path] = entry
+	case "file":
+		j.files[entry.Path] = entry
+	}
+	return j.f.Sync()
+}
+
+func (j *journal) Close() error {
+	if j == nil {
+		return nil
+	}
+	return j.f.Close()
+}
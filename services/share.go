@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/dvwzj/gofile/entity"
+	"github.com/dvwzj/gofile/params"
+)
+
+func (a API) ResolveShareURL(shareURL string, options ...params.GetContentOption) (*entity.Content, error) {
+	return a.ResolveShareURLContext(context.Background(), shareURL, options...)
+}
+
+// ResolveShareURLContext gets the content of a link handed out by gofile: a download page
+// (https://gofile.io/d/{code}), a direct or store server link (https://{server}.gofile.io/download/...)
+// or a content code alone. A direct link holds its own id, its file is looked for in the folders of the
+// account, with a request per folder. The links to the hosts of the client (WithBaseURL, WithUploadURLTemplate)
+// are also recognized, the others fail with an *entity.ShareURLError.
+func (a API) ResolveShareURLContext(ctx context.Context, shareURL string, options ...params.GetContentOption) (*entity.Content, error) {
+	target, err := a.parseShareURL(shareURL)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case target.code != "":
+		return a.GetContentByCodeContext(ctx, target.code, options...)
+	case target.directLinkId != "":
+		return a.findDirectLink(ctx, target.directLinkId, options...)
+	}
+	return a.GetContentContext(ctx, target.contentId, options...)
+}
+
+func (a API) GetContentByCode(code string, options ...params.GetContentOption) (*entity.Content, error) {
+	return a.GetContentByCodeContext(context.Background(), code, options...)
+}
+
+// GetContentByCodeContext gets the folder of code, the Code of entity.Content and entity.CreatedFolder.
+func (a API) GetContentByCodeContext(ctx context.Context, code string, options ...params.GetContentOption) (*entity.Content, error) {
+	if !isCode(code) {
+		return nil, &entity.ShareURLError{URL: code, Reason: "is not a content code"}
+	}
+	return a.GetContentContext(ctx, code, options...)
+}
+
+// shareTarget is what a share url points to, one of its fields is set.
+type shareTarget struct {
+	code         string
+	contentId    string
+	directLinkId string
+}
+
+func (a API) parseShareURL(shareURL string) (shareTarget, error) {
+	shareURL = strings.TrimSpace(shareURL)
+	if isCode(shareURL) {
+		return shareTarget{code: shareURL}, nil
+	}
+	if !strings.Contains(shareURL, "://") {
+		// e.g. gofile.io/d/AbC123
+		shareURL = "https://" + shareURL
+	}
+	u, err := url.Parse(shareURL)
+	if err != nil || u.Host == "" {
+		return shareTarget{}, &entity.ShareURLError{URL: shareURL, Reason: "is not a url"}
+	}
+	if !a.isShareHost(strings.ToLower(u.Host)) {
+		return shareTarget{}, &entity.ShareURLError{URL: shareURL, Reason: "is not a gofile host"}
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i, segment := range segments {
+		if segment == "d" && i+1 < len(segments) && isCode(segments[i+1]) {
+			return shareTarget{code: segments[i+1]}, nil
+		}
+		if segment != "download" || i+1 >= len(segments) {
+			continue
+		}
+		switch kind := segments[i+1]; {
+		case kind == "web" && i+2 < len(segments):
+			return shareTarget{contentId: segments[i+2]}, nil
+		case kind == "direct" && i+2 < len(segments):
+			return shareTarget{directLinkId: segments[i+2]}, nil
+		case kind != "web" && kind != "direct":
+			// the links without kind of the older store servers, /download/{id}/{name}
+			return shareTarget{contentId: kind}, nil
+		}
+	}
+	return shareTarget{}, &entity.ShareURLError{URL: shareURL, Reason: "is not a download page nor a download link"}
+}
+
+// findDirectLink walks the folders of the account to find the file of the direct link directLinkId,
+// every content is read with options.
+func (a API) findDirectLink(ctx context.Context, directLinkId string, options ...params.GetContentOption) (*entity.Content, error) {
+	account, err := a.GetAccountContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	folderIds := []string{account.RootFolder}
+	for len(folderIds) > 0 {
+		folder, err := a.GetContentContext(ctx, folderIds[0], options...)
+		if err != nil {
+			return nil, err
+		}
+		folderIds = folderIds[1:]
+		for _, file := range folder.Children.Files() {
+			if file.DirectLinks == nil {
+				continue
+			}
+			if _, ok := (*file.DirectLinks)[directLinkId]; ok {
+				return a.GetContentContext(ctx, file.Id, options...)
+			}
+		}
+		for _, sub := range folder.Children.Folders() {
+			folderIds = append(folderIds, sub.Id)
+		}
+	}
+	return nil, fmt.Errorf("%w: direct link %s", entity.ErrorNotFound, directLinkId)
+}
+
+// isShareHost reports whether host is gofile.io, one of its subdomains or a host of the client.
+func (a API) isShareHost(host string) bool {
+	if host == "gofile.io" || strings.HasSuffix(host, ".gofile.io") {
+		return true
+	}
+	patterns := []string{
+		hostOf(a.HttpClient().BaseURL),
+		// the name of the server is a wildcard, e.g. *.gofile.io
+		hostOf(a.API().UploadURL("*")),
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, host); ok && pattern != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// hostOf returns the lowercase host of rawURL, which may contain the wildcard of a server.
+func hostOf(rawURL string) string {
+	_, rest, ok := strings.Cut(rawURL, "://")
+	if !ok {
+		return ""
+	}
+	if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+		rest = rest[:i]
+	}
+	return strings.ToLower(rest)
+}
+
+func isCode(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dvwzj/gofile/entity"
+	"github.com/dvwzj/gofile/params"
+)
+
+func (a API) UploadArchive(dir string, format params.ArchiveFormat, options ...params.UploadArchiveOption) (*entity.UploadedFile, error) {
+	return a.UploadArchiveContext(context.Background(), dir, format, options...)
+}
+
+// UploadArchiveContext streams a tar.gz or zip of the regular files of dir as the body of a
+// single UploadFile, no temporary file is written. The files are archived in lexical order
+// with their modification time and permissions only, so the same tree gives the same md5.
+// As the archive can not be rewound, the upload is neither retried nor failed over.
+func (a API) UploadArchiveContext(ctx context.Context, dir string, format params.ArchiveFormat, options ...params.UploadArchiveOption) (*entity.UploadedFile, error) {
+	archiveParams := &params.UploadArchiveParams{}
+	for _, option := range options {
+		if err := option(archiveParams); err != nil {
+			return nil, err
+		}
+	}
+	if format != params.ArchiveTarGz && format != params.ArchiveZip {
+		return nil, fmt.Errorf("unknown archive format %q", format)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	fileName := filepath.Base(dir) + "." + string(format)
+	if archiveParams.FileName != nil {
+		fileName = *archiveParams.FileName
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeArchive(pw, os.DirFS(dir), format, archiveParams))
+	}()
+	uploadedFile, err := a.UploadFileContext(ctx, params.WithReader(pr, fileName), archiveParams.FileOptions...)
+	// the upload may stop before the archive is read to the end
+	pr.CloseWithError(io.ErrClosedPipe)
+	return uploadedFile, err
+}
+
+func writeArchive(w io.Writer, fsys fs.FS, format params.ArchiveFormat, archiveParams *params.UploadArchiveParams) error {
+	var addFile func(name string, info fs.FileInfo) error
+	var closeArchive func() error
+	switch format {
+	case params.ArchiveTarGz:
+		gzipWriter := gzip.NewWriter(w)
+		tarWriter := tar.NewWriter(gzipWriter)
+		addFile = func(name string, info fs.FileInfo) error {
+			if err := tarWriter.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeReg,
+				Name:     name,
+				Size:     info.Size(),
+				Mode:     int64(info.Mode().Perm()),
+				ModTime:  info.ModTime().UTC().Truncate(time.Second),
+				Format:   tar.FormatPAX,
+			}); err != nil {
+				return err
+			}
+			return copyFile(tarWriter, fsys, name)
+		}
+		closeArchive = func() error {
+			if err := tarWriter.Close(); err != nil {
+				return err
+			}
+			return gzipWriter.Close()
+		}
+	case params.ArchiveZip:
+		zipWriter := zip.NewWriter(w)
+		addFile = func(name string, info fs.FileInfo) error {
+			header, err := zip.FileInfoHeader(info)
+			if err != nil {
+				return err
+			}
+			header.Name = name
+			header.Method = zip.Deflate
+			header.Modified = info.ModTime().UTC().Truncate(time.Second)
+			fileWriter, err := zipWriter.CreateHeader(header)
+			if err != nil {
+				return err
+			}
+			return copyFile(fileWriter, fsys, name)
+		}
+		closeArchive = zipWriter.Close
+	}
+	// fs.WalkDir visits the entries in lexical order
+	err := fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name == "." {
+			return nil
+		}
+		if entry.IsDir() {
+			if archiveParams.Excluded(name) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() || !archiveParams.Match(name) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		return addFile(name, info)
+	})
+	if err != nil {
+		return err
+	}
+	return closeArchive()
+}
+
+func copyFile(w io.Writer, fsys fs.FS, name string) error {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(w, file)
+	return err
+}
@@ -0,0 +1,290 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+
+	"github.com/dvwzj/gofile/entity"
+	"github.com/dvwzj/gofile/params"
+)
+
+// dirTree is a directory uploaded by UploadDir or UploadFS, the names are the paths of fsys.
+type dirTree struct {
+	fsys fs.FS
+	// localPath is the path of a name in the results and the journal
+	localPath func(name string) string
+	// realPath resolves the symbolic links of a name to find the cycles
+	realPath func(name string) (string, error)
+}
+
+func (a API) UploadDir(localPath, parentFolderId string, options ...params.UploadBatchOption) (*entity.UploadedDir, error) {
+	return a.UploadDirContext(context.Background(), localPath, parentFolderId, options...)
+}
+
+// UploadDirContext creates a folder for localPath in parentFolderId (the root folder of the account
+// when empty) and the folders of its tree, then uploads the files with a pool of workers, to servers
+// selected once for the whole tree. The result
+// is returned with the errors of the directories and files that failed, joined. With params.WithJournal
+// a rerun with the same localPath and parentFolderId only uploads what is missing.
+func (a API) UploadDirContext(ctx context.Context, localPath, parentFolderId string, options ...params.UploadBatchOption) (*entity.UploadedDir, error) {
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", localPath)
+	}
+	tree := dirTree{
+		fsys: os.DirFS(localPath),
+		localPath: func(name string) string {
+			return filepath.Join(localPath, filepath.FromSlash(name))
+		},
+		realPath: func(name string) (string, error) {
+			return filepath.EvalSymlinks(filepath.Join(localPath, filepath.FromSlash(name)))
+		},
+	}
+	return a.uploadTree(ctx, tree, filepath.Base(localPath), parentFolderId, options)
+}
+
+func (a API) UploadFS(fsys fs.FS, root, parentFolderId string, options ...params.UploadBatchOption) (*entity.UploadedDir, error) {
+	return a.UploadFSContext(context.Background(), fsys, root, parentFolderId, options...)
+}
+
+// UploadFSContext is UploadDirContext for the directory root of fsys (e.g. an embed.FS, a zip.Reader
+// or an fstest.MapFS), the local paths of the result are the names of fsys. A root of "." uploads the
+// content of fsys in parentFolderId instead of creating a folder for it.
+func (a API) UploadFSContext(ctx context.Context, fsys fs.FS, root, parentFolderId string, options ...params.UploadBatchOption) (*entity.UploadedDir, error) {
+	if fsys == nil {
+		return nil, errors.New("fsys is nil")
+	}
+	info, err := fs.Stat(fsys, root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+	sub, err := fs.Sub(fsys, root)
+	if err != nil {
+		return nil, err
+	}
+	tree := dirTree{
+		fsys: sub,
+		localPath: func(name string) string {
+			return path.Join(root, name)
+		},
+		realPath: func(name string) (string, error) {
+			return path.Join(root, name), nil
+		},
+	}
+	folderName := ""
+	if root != "." {
+		folderName = path.Base(root)
+	}
+	return a.uploadTree(ctx, tree, folderName, parentFolderId, options)
+}
+
+// uploadTree creates a folder named folderName in parentFolderId, or uses parentFolderId when
+// folderName is empty, and mirrors tree into it.
+func (a API) uploadTree(ctx context.Context, tree dirTree, folderName, parentFolderId string, options []params.UploadBatchOption) (*entity.UploadedDir, error) {
+	dirParams := &params.UploadBatchParams{
+		Workers: params.DefaultUploadWorkers,
+	}
+	for _, option := range options {
+		if err := option(dirParams); err != nil {
+			return nil, err
+		}
+	}
+	if parentFolderId == "" {
+		account, err := a.GetAccountContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		parentFolderId = account.RootFolder
+	}
+	var j *journal
+	if dirParams.JournalPath != "" {
+		var err error
+		if j, err = openJournal(dirParams.JournalPath); err != nil {
+			return nil, err
+		}
+		defer j.Close()
+	}
+	root := &entity.UploadedDir{LocalPath: tree.localPath(".")}
+	jobs := []*entity.UploadedDirFile{}
+	names := map[*entity.UploadedDirFile]string{}
+	folderIds := map[*entity.UploadedDirFile]string{}
+	var walk func(dir *entity.UploadedDir, name, folderName, parentFolderId string, visited map[string]bool) error
+	walk = func(dir *entity.UploadedDir, name, folderName, parentFolderId string, visited map[string]bool) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if folderName == "" {
+			dir.FolderId = parentFolderId
+		} else if entry, ok := j.folder(dir.LocalPath, parentFolderId); ok {
+			dir.FolderId = entry.FolderId
+			dir.Code = entry.Code
+			dir.Resumed = true
+		} else {
+			folder, err := a.CreateFolderContext(ctx, parentFolderId, params.WithFolderName(folderName))
+			if err != nil {
+				dir.Err = err
+				return nil
+			}
+			dir.FolderId = folder.FolderId
+			dir.Code = folder.Code
+			if err := j.record(journalEntry{
+				Type:           "folder",
+				Path:           dir.LocalPath,
+				ParentFolderId: parentFolderId,
+				FolderId:       folder.FolderId,
+				Code:           folder.Code,
+			}); err != nil {
+				return err
+			}
+		}
+		entries, err := fs.ReadDir(tree.fsys, name)
+		if err != nil {
+			dir.Err = err
+			return nil
+		}
+		subNames := []string{}
+		for _, entry := range entries {
+			entryName := path.Join(name, entry.Name())
+			localPath := tree.localPath(entryName)
+			isDir := entry.IsDir()
+			if entry.Type()&fs.ModeSymlink != 0 {
+				switch dirParams.SymlinkPolicy {
+				case params.SymlinkSkip:
+					dir.Files = append(dir.Files, entity.UploadedDirFile{LocalPath: localPath, Skipped: true})
+					continue
+				case params.SymlinkFail:
+					dir.Files = append(dir.Files, entity.UploadedDirFile{LocalPath: localPath, Err: fmt.Errorf("%s is a symbolic link", localPath)})
+					continue
+				}
+				target, err := fs.Stat(tree.fsys, entryName)
+				if err != nil {
+					dir.Files = append(dir.Files, entity.UploadedDirFile{LocalPath: localPath, Err: err})
+					continue
+				}
+				isDir = target.IsDir()
+			}
+			if !isDir {
+				if !entry.Type().IsRegular() && entry.Type()&fs.ModeSymlink == 0 {
+					dir.Files = append(dir.Files, entity.UploadedDirFile{LocalPath: localPath, Skipped: true})
+					continue
+				}
+				dir.Files = append(dir.Files, entity.UploadedDirFile{LocalPath: localPath})
+				continue
+			}
+			dir.Folders = append(dir.Folders, &entity.UploadedDir{LocalPath: localPath})
+			subNames = append(subNames, entryName)
+		}
+		// the files of a directory are uploaded before the ones of its folders
+		for i := range dir.Files {
+			file := &dir.Files[i]
+			if !file.Skipped && file.Err == nil {
+				jobs = append(jobs, file)
+				names[file] = path.Join(name, path.Base(filepath.ToSlash(file.LocalPath)))
+				folderIds[file] = dir.FolderId
+			}
+		}
+		for i, sub := range dir.Folders {
+			realPath, err := tree.realPath(subNames[i])
+			if err != nil {
+				sub.Err = err
+				continue
+			}
+			if visited[realPath] {
+				sub.Err = fmt.Errorf("%s is a cycle of symbolic links", sub.LocalPath)
+				continue
+			}
+			visited[realPath] = true
+			err = walk(sub, subNames[i], path.Base(subNames[i]), dir.FolderId, visited)
+			delete(visited, realPath)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	realRoot, err := tree.realPath(".")
+	if err != nil {
+		return nil, err
+	}
+	if err := walk(root, ".", folderName, parentFolderId, map[string]bool{realRoot: true}); err != nil {
+		return root, err
+	}
+	if root.Err != nil {
+		return root, root.Err
+	}
+	if len(jobs) == 0 {
+		return root, root.Errs()
+	}
+	// the servers are selected once for the whole tree
+	_, fileOptions, err := a.batchFileOptions(ctx, dirParams.FileOptions)
+	if err != nil {
+		return root, err
+	}
+	queue := make(chan *entity.UploadedDirFile)
+	wg := sync.WaitGroup{}
+	for i := 0; i < dirParams.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range queue {
+				a.uploadTreeFile(ctx, j, tree.fsys, names[file], file, folderIds[file], fileOptions)
+			}
+		}()
+	}
+	for _, file := range jobs {
+		queue <- file
+	}
+	close(queue)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return root, err
+	}
+	return root, root.Errs()
+}
+
+func (a API) uploadTreeFile(ctx context.Context, j *journal, fsys fs.FS, name string, file *entity.UploadedDirFile, folderId string, options []params.UploadFileOption) {
+	if err := ctx.Err(); err != nil {
+		file.Err = err
+		return
+	}
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		file.Err = err
+		return
+	}
+	if entry, ok := j.file(file.LocalPath, folderId, info); ok {
+		file.FileId = entry.FileId
+		file.MD5 = entry.MD5
+		file.Resumed = true
+		return
+	}
+	options = append(append([]params.UploadFileOption{}, options...), params.WithFolderId(folderId))
+	uploadedFile, err := a.UploadFileContext(ctx, params.WithFS(fsys, name), options...)
+	if err != nil {
+		file.Err = err
+		return
+	}
+	file.FileId = uploadedFile.FileId
+	file.MD5 = uploadedFile.MD5
+	file.Err = j.record(journalEntry{
+		Type:     "file",
+		Path:     file.LocalPath,
+		FolderId: folderId,
+		FileId:   uploadedFile.FileId,
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+		MD5:      uploadedFile.MD5,
+	})
+}
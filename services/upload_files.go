@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/dvwzj/gofile/entity"
+	"github.com/dvwzj/gofile/params"
+)
+
+func (a API) UploadFiles(files []params.UploadFile, options ...params.UploadBatchOption) ([]entity.UploadResult, error) {
+	return a.UploadFilesContext(context.Background(), files, options...)
+}
+
+// UploadFilesContext uploads files with a pool of workers and returns a result per file, in the
+// order of files, with the errors of the files that failed joined. The servers are selected once
+// for the whole batch. Without params.WithFolderId the files are uploaded one by one until one
+// succeeds, the others then go to the folder it created.
+func (a API) UploadFilesContext(ctx context.Context, files []params.UploadFile, options ...params.UploadBatchOption) ([]entity.UploadResult, error) {
+	batchParams := &params.UploadBatchParams{
+		Workers: params.DefaultUploadWorkers,
+	}
+	for _, option := range options {
+		if err := option(batchParams); err != nil {
+			return nil, err
+		}
+	}
+	fileParams, fileOptions, err := a.batchFileOptions(ctx, batchParams.FileOptions)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]entity.UploadResult, len(files))
+	upload := func(i int, options []params.UploadFileOption) {
+		if err := ctx.Err(); err != nil {
+			results[i].Err = err
+			return
+		}
+		results[i].File, results[i].Err = a.UploadFileContext(ctx, files[i], options...)
+	}
+	next := 0
+	if fileParams.FolderId == nil {
+		for ; next < len(files); next++ {
+			upload(next, fileOptions)
+			if first := results[next].File; first != nil {
+				fileOptions = append(fileOptions, params.WithFolderId(first.ParentFolder))
+				if first.GuestToken != "" {
+					fileOptions = append(fileOptions, params.WithAuthToken(first.GuestToken))
+				}
+				next++
+				break
+			}
+		}
+	}
+	queue := make(chan int)
+	wg := sync.WaitGroup{}
+	for i := 0; i < batchParams.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				upload(i, fileOptions)
+			}
+		}()
+	}
+	for ; next < len(files); next++ {
+		queue <- next
+	}
+	close(queue)
+	wg.Wait()
+	errs := []error{}
+	for i, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("file %d: %w", i, result.Err))
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+// batchFileOptions returns the params of the options shared by the files of a batch, which tell
+// the folder and how to select the servers, and the options with the servers selected once for all.
+func (a API) batchFileOptions(ctx context.Context, options []params.UploadFileOption) (*params.UploadFileParams, []params.UploadFileOption, error) {
+	fileParams := &params.UploadFileParams{}
+	for _, option := range options {
+		if err := option(fileParams); err != nil {
+			return nil, nil, err
+		}
+	}
+	options = append([]params.UploadFileOption{}, options...)
+	if fileParams.Server != nil || len(fileParams.ServerNames) > 0 {
+		return fileParams, options, nil
+	}
+	candidates, err := a.API().SelectServersContext(ctx, fileParams.ServerSelector)
+	if err != nil {
+		return nil, nil, err
+	}
+	serverNames := []string{}
+	for _, candidate := range candidates {
+		serverNames = append(serverNames, candidate.Server.Name)
+	}
+	return fileParams, append(options, params.WithServerNames(serverNames...)), nil
+}
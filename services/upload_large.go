@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/dvwzj/gofile/entity"
+	"github.com/dvwzj/gofile/params"
+)
+
+const (
+	largeManifestVersion = 1
+	largeManifestSuffix  = ".manifest.json"
+)
+
+func (a API) UploadLarge(file params.UploadFile, options ...params.UploadBatchOption) (*entity.LargeUpload, error) {
+	return a.UploadLargeContext(context.Background(), file, options...)
+}
+
+// UploadLargeContext splits file in parts of params.WithPartSize, uploads them with a pool of workers
+// in a folder created for the file, then uploads the manifest of the parts. The folder is created in
+// the folder of params.WithFolderId given with params.WithFileOptions, or the root folder of the account.
+// The folder is deleted when a part fails. The file name, or the one of params.WithFileName, names
+// the folder, the parts and the manifest, the name options only apply to it. The parts can not be
+// encrypted, params.WithEncryption fails.
+func (a API) UploadLargeContext(ctx context.Context, file params.UploadFile, options ...params.UploadBatchOption) (*entity.LargeUpload, error) {
+	batchParams := &params.UploadBatchParams{
+		Workers:  params.DefaultUploadWorkers,
+		PartSize: params.DefaultPartSize,
+	}
+	for _, option := range options {
+		if err := option(batchParams); err != nil {
+			return nil, err
+		}
+	}
+	fileParams := &params.UploadFileParams{}
+	err := file(fileParams)
+	if fileParams.Closer != nil {
+		defer fileParams.Closer.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, option := range batchParams.FileOptions {
+		if err := option(fileParams); err != nil {
+			return nil, err
+		}
+	}
+	if fileParams.EncryptFileName {
+		// DownloadLarge finds the manifest and the parts by their names
+		return nil, errors.New("the file names of UploadLarge can not be encrypted")
+	}
+	if fileParams.Encryption != nil {
+		// DownloadLarge reads the manifest and checks the md5 of the parts against the data it gets
+		return nil, errors.New("the files of UploadLarge can not be encrypted")
+	}
+	parentFolderId := ""
+	if fileParams.FolderId != nil {
+		parentFolderId = *fileParams.FolderId
+	} else {
+		account, err := a.GetAccountContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		parentFolderId = account.RootFolder
+	}
+	fileName := *fileParams.FileName
+	folder, err := a.CreateFolderContext(ctx, parentFolderId, params.WithFolderName(fileName))
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := a.uploadParts(ctx, fileParams.FileReader, fileName, folder.FolderId, batchParams)
+	if err == nil {
+		var data []byte
+		if data, err = json.Marshal(manifest); err == nil {
+			var uploadedFile *entity.UploadedFile
+			manifestName := fileName + largeManifestSuffix
+			uploadedFile, err = a.UploadFileContext(ctx, params.WithBytes(data, manifestName), a.partOptions(batchParams, folder.FolderId, manifestName)...)
+			if err == nil {
+				return &entity.LargeUpload{
+					FolderId:   folder.FolderId,
+					Code:       folder.Code,
+					ManifestId: uploadedFile.FileId,
+					Manifest:   *manifest,
+				}, nil
+			}
+		}
+	}
+	// the parts are useless without their manifest
+	if deleteErr := a.DeleteContentContext(context.WithoutCancel(ctx), folder.FolderId); deleteErr != nil {
+		return nil, errors.Join(err, deleteErr)
+	}
+	return nil, err
+}
+
+// partOptions returns the options of a part or the manifest, their folder and name override the ones
+// given with params.WithFileOptions.
+func (a API) partOptions(batchParams *params.UploadBatchParams, folderId, fileName string) []params.UploadFileOption {
+	return append(append([]params.UploadFileOption{}, batchParams.FileOptions...), params.WithFolderId(folderId), params.WithFileName(fileName))
+}
+
+type largePart struct {
+	entity.LargePart
+	data []byte
+}
+
+// uploadParts reads reader part by part, a part is uploaded by the first free worker.
+func (a API) uploadParts(ctx context.Context, reader io.Reader, fileName, folderId string, batchParams *params.UploadBatchParams) (*entity.LargeManifest, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	manifest := &entity.LargeManifest{
+		Version:  largeManifestVersion,
+		FileName: fileName,
+		PartSize: batchParams.PartSize,
+		Parts:    []entity.LargePart{},
+	}
+	queue := make(chan *largePart)
+	mu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for i := 0; i < batchParams.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for part := range queue {
+				uploadedFile, err := a.UploadFileContext(ctx, params.WithBytes(part.data, part.FileName), a.partOptions(batchParams, folderId, part.FileName)...)
+				if err != nil {
+					cancel(fmt.Errorf("%s: %w", part.FileName, err))
+					continue
+				}
+				part.FileId = uploadedFile.FileId
+				mu.Lock()
+				manifest.Parts[part.Index] = part.LargePart
+				mu.Unlock()
+			}
+		}()
+	}
+	total := sha256.New()
+	err := func() error {
+		defer close(queue)
+		for index := 0; ; index++ {
+			data := make([]byte, batchParams.PartSize)
+			n, err := io.ReadFull(reader, data)
+			if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+				return err
+			}
+			if n == 0 {
+				return nil
+			}
+			total.Write(data[:n])
+			part := &largePart{
+				LargePart: entity.LargePart{
+					Index:    index,
+					FileName: fmt.Sprintf("%s.part%04d", fileName, index+1),
+					Size:     int64(n),
+					MD5:      hexSum(md5.New(), data[:n]),
+				},
+				data: data[:n],
+			}
+			manifest.Size += int64(n)
+			mu.Lock()
+			manifest.Parts = append(manifest.Parts, entity.LargePart{})
+			mu.Unlock()
+			select {
+			case queue <- part:
+			case <-ctx.Done():
+				return context.Cause(ctx)
+			}
+			if n < len(data) {
+				return nil
+			}
+		}
+	}()
+	wg.Wait()
+	if err == nil {
+		err = context.Cause(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	manifest.SHA256 = hex.EncodeToString(total.Sum(nil))
+	return manifest, nil
+}
+
+func hexSum(h hash.Hash, data []byte) string {
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (a API) DownloadLarge(folderId string, dst io.Writer, options ...params.DownloadOption) (*entity.LargeManifest, error) {
+	return a.DownloadLargeContext(context.Background(), folderId, dst, options...)
+}
+
+// DownloadLargeContext reads the manifest of the folder created by UploadLarge, downloads its parts with
+// a pool of workers and writes them in order to dst. The md5 of every part and the sha256 of the file are
+// checked, a mismatch fails with entity.ErrChecksumMismatch after dst was written. Only
+// params.WithDownloadWorkers applies, params.WithDecryption and params.WithResumeAttempts fail.
+func (a API) DownloadLargeContext(ctx context.Context, folderId string, dst io.Writer, options ...params.DownloadOption) (*entity.LargeManifest, error) {
+	downloadParams := &params.DownloadParams{
+		Workers: params.DefaultDownloadWorkers,
+	}
+	for _, option := range options {
+		if err := option(downloadParams); err != nil {
+			return nil, err
+		}
+	}
+	if downloadParams.Decryption != nil {
+		return nil, errors.New("the files of UploadLarge are not encrypted")
+	}
+	if downloadParams.ResumeAttempts > 0 {
+		return nil, errors.New("the parts of DownloadLarge are not resumed")
+	}
+	folder, err := a.GetContentContext(ctx, folderId)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]entity.ChildContentFile{}
+	var manifestFile *entity.ChildContentFile
+	for _, file := range folder.Children.Files() {
+		files[file.Id] = file
+		if strings.HasSuffix(file.Name, largeManifestSuffix) {
+			manifestFile = &file
+		}
+	}
+	if manifestFile == nil {
+		return nil, fmt.Errorf("%w: no manifest in folder %s", entity.ErrorNotFound, folderId)
+	}
+	data, err := a.downloadBytes(ctx, manifestFile.Link)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &entity.LargeManifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("%s: %w", manifestFile.Name, err)
+	}
+	if manifest.Version != largeManifestVersion {
+		return nil, fmt.Errorf("%s: unknown version %d", manifestFile.Name, manifest.Version)
+	}
+	links := []string{}
+	for _, part := range manifest.Parts {
+		file, ok := files[part.FileId]
+		if !ok {
+			return nil, fmt.Errorf("%w: part %s", entity.ErrorNotFound, part.FileName)
+		}
+		links = append(links, file.Link)
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	// a worker slot is freed once its part is written, which bounds the parts held in memory
+	slots := make(chan struct{}, downloadParams.Workers)
+	results := make([]chan []byte, len(manifest.Parts))
+	for i := range results {
+		results[i] = make(chan []byte, 1)
+	}
+	go func() {
+		for i, part := range manifest.Parts {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			go func(i int, part entity.LargePart) {
+				data, err := a.downloadBytes(ctx, links[i])
+				if err == nil && (int64(len(data)) != part.Size || hexSum(md5.New(), data) != part.MD5) {
+					err = &entity.ChecksumError{
+						FileId:   part.FileId,
+						Expected: part.MD5,
+						Actual:   hexSum(md5.New(), data),
+					}
+				}
+				if err != nil {
+					cancel(fmt.Errorf("%s: %w", part.FileName, err))
+					return
+				}
+				results[i] <- data
+			}(i, part)
+		}
+	}()
+	total := sha256.New()
+	for i := range manifest.Parts {
+		select {
+		case data := <-results[i]:
+			if _, err := io.MultiWriter(dst, total).Write(data); err != nil {
+				return nil, err
+			}
+			<-slots
+		case <-ctx.Done():
+			return nil, context.Cause(ctx)
+		}
+	}
+	if sum := hex.EncodeToString(total.Sum(nil)); sum != manifest.SHA256 {
+		return nil, fmt.Errorf("%w: sha256 of %s is %s, the manifest has %s", entity.ErrChecksumMismatch, manifest.FileName, sum, manifest.SHA256)
+	}
+	return manifest, nil
+}
+
+func (a API) downloadBytes(ctx context.Context, link string) ([]byte, error) {
+	resp, err := a.API().OpenLinkContext(ctx, link, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
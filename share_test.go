@@ -0,0 +1,119 @@
+package gofile_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dvwzj/gofile"
+	"github.com/dvwzj/gofile/entity"
+	"github.com/dvwzj/gofile/gofiletest"
+	"github.com/dvwzj/gofile/params"
+)
+
+func TestResolveShareURL(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	account := server.NewAccount(entity.AccountTierPremium)
+	client, err := server.NewClient(gofile.WithToken(account.Token))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	folder, err := client.CreateFolder(account.RootFolder, params.WithFolderName("shared"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the file of a direct link is looked for in the subfolders
+	uploaded, err := client.UploadFile(params.WithBytes([]byte("data"), "data.txt"), params.WithFolderId(folder.FolderId))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := client.GetContentByCode(folder.Code)
+	if err != nil || content.Id != folder.FolderId {
+		t.Fatalf("unexpected content: %+v, %v", content, err)
+	}
+	base, _ := url.Parse(server.URL)
+	for _, shareURL := range []string{
+		folder.Code,
+		server.URL + "/d/" + folder.Code,
+		server.URL + "/d/" + folder.Code + "?ref=chat",
+		base.Host + "/d/" + folder.Code,
+	} {
+		content, err := client.ResolveShareURL(shareURL)
+		if err != nil || content.Id != folder.FolderId {
+			t.Fatalf("unexpected content of %s: %+v, %v", shareURL, content, err)
+		}
+	}
+
+	content, err = client.GetContent(folder.FolderId)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	file := content.Children.Files()[0]
+	directLink, err := client.CreateDirectLink(uploaded.FileId, entity.DirectLink{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, shareURL := range []string{file.Link, directLink.DirectLink, strings.Replace(file.Link, "/download/web/", "/download/", 1)} {
+		content, err := client.ResolveShareURL(shareURL)
+		if err != nil || content.Id != uploaded.FileId {
+			t.Fatalf("unexpected content of %s: %+v, %v", shareURL, content, err)
+		}
+	}
+
+	// the options are given to every content read to find a direct link
+	mu := sync.Mutex{}
+	passwords := []string{}
+	target, _ := url.Parse(server.URL)
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	recorder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/contents/") {
+			mu.Lock()
+			passwords = append(passwords, r.URL.Query().Get("password"))
+			mu.Unlock()
+		}
+		proxy.ServeHTTP(w, r)
+	}))
+	defer recorder.Close()
+	proxied, err := server.NewClient(gofile.WithToken(account.Token), gofile.WithBaseURL(recorder.URL))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err = proxied.ResolveShareURL(directLink.DirectLink, params.WithContentPassword("s3cret"))
+	if err != nil || content.Id != uploaded.FileId {
+		t.Fatalf("unexpected content: %+v, %v", content, err)
+	}
+	for _, password := range passwords {
+		if password == "" {
+			t.Fatalf("content read without password: %v", passwords)
+		}
+	}
+	if len(passwords) < 2 {
+		t.Fatalf("unexpected content reads: %v", passwords)
+	}
+
+	for _, shareURL := range []string{
+		"",
+		"not a code",
+		"https://example.com/d/" + folder.Code,
+		server.URL + "/contents/" + folder.FolderId,
+		"https://gofile.io/d/",
+	} {
+		var shareErr *entity.ShareURLError
+		if _, err := client.ResolveShareURL(shareURL); !errors.As(err, &shareErr) || !errors.Is(err, entity.ErrShareURL) {
+			t.Fatalf("unexpected error for %q: %v", shareURL, err)
+		}
+	}
+	if _, err := client.GetContentByCode("not-a-code"); !errors.Is(err, entity.ErrShareURL) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.ResolveShareURL("https://gofile.io/d/zzzzzzzz"); err == nil || errors.Is(err, entity.ErrShareURL) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package gofile_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/dvwzj/gofile"
+	"github.com/dvwzj/gofile/entity"
+	"github.com/dvwzj/gofile/gofiletest"
+	"github.com/dvwzj/gofile/params"
+)
+
+func TestUploadArchive(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	account := server.NewAccount(entity.AccountTierStandard)
+	client, err := server.NewClient(gofile.WithToken(account.Token))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	root := filepath.Join(t.TempDir(), "logs")
+	writeTree(t, root, map[string]string{
+		"b.log":         "b",
+		"a.log":         "a",
+		"a.tmp":         "tmp",
+		"app/z.log":     "z",
+		"app/debug.txt": "debug",
+		"cache/c.log":   "cache",
+	})
+	options := []params.UploadArchiveOption{
+		params.WithInclude("*.log", "app/*"),
+		params.WithExclude("cache"),
+	}
+
+	uploadedFile, err := client.UploadArchive(root, params.ArchiveTarGz, options...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if uploadedFile.FileName != "logs.tar.gz" {
+		t.Fatalf("unexpected file name: %s", uploadedFile.FileName)
+	}
+	data, _ := server.FileData(uploadedFile.FileId)
+	gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tarReader := tar.NewReader(gzipReader)
+	names := []string{}
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		names = append(names, header.Name)
+	}
+	expected := []string{"a.log", "app/debug.txt", "app/z.log", "b.log"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("unexpected entries: %v", names)
+	}
+	again, err := client.UploadArchive(root, params.ArchiveTarGz, options...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again.MD5 != uploadedFile.MD5 {
+		t.Fatalf("archive is not reproducible: %s, %s", again.MD5, uploadedFile.MD5)
+	}
+
+	uploadedFile, err = client.UploadArchive(root, params.ArchiveZip, append(options, params.WithArchiveName("bundle.zip"))...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ = server.FileData(uploadedFile.FileId)
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	names = []string{}
+	for _, file := range zipReader.File {
+		names = append(names, file.Name)
+	}
+	if uploadedFile.FileName != "bundle.zip" || !reflect.DeepEqual(names, expected) {
+		t.Fatalf("unexpected archive %s: %v", uploadedFile.FileName, names)
+	}
+	again, err = client.UploadArchive(root, params.ArchiveZip, options...)
+	if err != nil || again.MD5 != uploadedFile.MD5 {
+		t.Fatalf("archive is not reproducible: %v", err)
+	}
+
+	if _, err := client.UploadArchive(filepath.Join(root, "missing"), params.ArchiveZip); err == nil {
+		t.Fatalf("unexpected nil error")
+	}
+}
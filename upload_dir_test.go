@@ -0,0 +1,289 @@
+package gofile_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/dvwzj/gofile"
+	"github.com/dvwzj/gofile/entity"
+	"github.com/dvwzj/gofile/gofiletest"
+	"github.com/dvwzj/gofile/params"
+)
+
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, data := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestUploadDir(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	account := server.NewAccount(entity.AccountTierPremium)
+	client, err := server.NewClient(gofile.WithToken(account.Token))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	root := filepath.Join(t.TempDir(), "build")
+	files := map[string]string{
+		"index.html":         "index",
+		"assets/app.js":      "app",
+		"assets/css/app.css": "css",
+		"empty/.keep":        "",
+	}
+	writeTree(t, root, files)
+	if err := os.Symlink(filepath.Join(root, "index.html"), filepath.Join(root, "link.html")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.Symlink(root, filepath.Join(root, "assets", "loop")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	uploadedDir, err := client.UploadDir(root, "", params.WithWorkers(2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the servers are selected once for the tree
+	if n := server.Requests(http.MethodHead, "/store/store1"); n != 1 || server.Requests(http.MethodGet, "/servers") != 1 {
+		t.Fatalf("unexpected server selections: %d probes", n)
+	}
+	ids := uploadedDir.Ids()
+	for name, data := range files {
+		fileId, ok := ids[filepath.Join(root, name)]
+		if !ok {
+			t.Fatalf("%s was not uploaded", name)
+		}
+		if got, _ := server.FileData(fileId); !bytes.Equal(got, []byte(data)) {
+			t.Fatalf("unexpected data for %s: %q", name, got)
+		}
+	}
+	if _, ok := ids[filepath.Join(root, "link.html")]; ok || len(uploadedDir.Files) != 2 || !uploadedDir.Files[1].Skipped {
+		t.Fatalf("unexpected files: %+v", uploadedDir.Files)
+	}
+	content, err := client.GetContent(ids[filepath.Join(root, "assets")])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content.Name != "assets" || content.ParentFolder != uploadedDir.FolderId || len(content.ChildrenIds) != 2 {
+		t.Fatalf("unexpected folder: %+v", content)
+	}
+	if content, err := client.GetContent(uploadedDir.FolderId); err != nil || content.Name != "build" || content.ParentFolder != account.RootFolder {
+		t.Fatalf("unexpected folder: %+v, %v", content, err)
+	}
+
+	uploadedDir, err = client.UploadDir(root, account.RootFolder, params.WithSymlinkPolicy(params.SymlinkFollow))
+	if err == nil {
+		t.Fatalf("unexpected nil error")
+	}
+	ids = uploadedDir.Ids()
+	if linkId, ok := ids[filepath.Join(root, "link.html")]; !ok {
+		t.Fatalf("link was not followed")
+	} else if got, _ := server.FileData(linkId); string(got) != "index" {
+		t.Fatalf("unexpected link data: %q", got)
+	}
+	loop := uploadedDir.Folders[0].Folders[1]
+	if loop.LocalPath != filepath.Join(root, "assets", "loop") || loop.Err == nil || loop.FolderId != "" {
+		t.Fatalf("unexpected loop: %+v", loop)
+	}
+
+	_, err = client.UploadDir(root, "missing")
+	if !errors.Is(err, entity.ErrorNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestUploadDirJournal(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	account := server.NewAccount(entity.AccountTierStandard)
+	client, err := server.NewClient(gofile.WithToken(account.Token))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	root := filepath.Join(t.TempDir(), "build")
+	writeTree(t, root, map[string]string{
+		"a.txt":     "a",
+		"b.txt":     "b",
+		"sub/c.txt": "c",
+	})
+	journalPath := filepath.Join(t.TempDir(), "journal")
+	uploadPath := "/store/store1/contents/uploadfile"
+	options := []params.UploadBatchOption{
+		params.WithJournal(journalPath),
+		params.WithWorkers(1),
+		params.WithFileOptions(params.WithServerName("store1")),
+	}
+
+	server.FailNext(http.MethodPost, uploadPath, http.StatusBadRequest, 1)
+	first, err := client.UploadDir(root, "", options...)
+	if err == nil {
+		t.Fatalf("unexpected nil error")
+	}
+	if n := server.Requests(http.MethodPost, "/contents/createFolder"); n != 2 {
+		t.Fatalf("unexpected folders created: %d", n)
+	}
+
+	second, err := client.UploadDir(root, "", options...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := server.Requests(http.MethodPost, "/contents/createFolder"); n != 2 {
+		t.Fatalf("folders were created again: %d", n)
+	}
+	if n := server.Requests(http.MethodPost, uploadPath); n != 4 {
+		t.Fatalf("unexpected uploads: %d", n)
+	}
+	if !second.Resumed || second.FolderId != first.FolderId || second.Files[0].Resumed || !second.Files[1].Resumed || !second.Folders[0].Files[0].Resumed {
+		t.Fatalf("unexpected result: %+v", second)
+	}
+	if data, _ := server.FileData(second.Files[0].FileId); string(data) != "a" {
+		t.Fatalf("unexpected file data: %q", data)
+	}
+
+	modTime := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(root, "b.txt"), modTime, modTime); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.UploadDir(root, "", options...); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := server.Requests(http.MethodPost, uploadPath); n != 5 {
+		t.Fatalf("unexpected uploads: %d", n)
+	}
+}
+
+func TestUploadDirJournalTruncated(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	account := server.NewAccount(entity.AccountTierStandard)
+	client, err := server.NewClient(gofile.WithToken(account.Token))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	root := filepath.Join(t.TempDir(), "build")
+	writeTree(t, root, map[string]string{
+		"a.txt": "a",
+		"b.txt": "b",
+	})
+	journalPath := filepath.Join(t.TempDir(), "journal")
+	uploadPath := "/store/store1/contents/uploadfile"
+	options := []params.UploadBatchOption{
+		params.WithJournal(journalPath),
+		params.WithFileOptions(params.WithServerName("store1")),
+	}
+	if _, err := client.UploadDir(root, "", options...); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a crash cut the last line of the journal
+	journal, err := os.OpenFile(journalPath, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	journal.WriteString(`{"type":"file","path":`)
+	journal.Close()
+	modTime := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(root, "b.txt"), modTime, modTime); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.UploadDir(root, "", options...); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := server.Requests(http.MethodPost, uploadPath); n != 3 {
+		t.Fatalf("unexpected uploads: %d", n)
+	}
+
+	// the entry of b.txt was not appended to the cut line
+	resumed, err := client.UploadDir(root, "", options...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := server.Requests(http.MethodPost, uploadPath); n != 3 || !resumed.Files[1].Resumed {
+		t.Fatalf("unexpected uploads: %d", n)
+	}
+}
+
+func TestUploadFS(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	account := server.NewAccount(entity.AccountTierPremium)
+	client, err := server.NewClient(gofile.WithToken(account.Token))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fsys := fstest.MapFS{
+		"assets/logo.svg":     {Data: []byte("<svg/>")},
+		"assets/css/app.css":  {Data: []byte("body{}")},
+		"assets/js/vendor.js": {Data: []byte("vendor")},
+		"README":              {Data: []byte("readme")},
+	}
+	uploadedFile, err := client.UploadFile(params.WithFS(fsys, "README"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if uploadedFile.FileName != "README" {
+		t.Fatalf("unexpected file name: %s", uploadedFile.FileName)
+	}
+	if _, err := client.UploadFile(params.WithFS(fsys, "assets")); err == nil {
+		t.Fatalf("unexpected nil error")
+	}
+
+	uploadedDir, err := client.UploadFS(fsys, "assets", account.RootFolder)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ids := uploadedDir.Ids()
+	for _, name := range []string{"assets/logo.svg", "assets/css/app.css", "assets/js/vendor.js"} {
+		if data, _ := server.FileData(ids[name]); !bytes.Equal(data, fsys[name].Data) {
+			t.Fatalf("unexpected data for %s: %q", name, data)
+		}
+	}
+	if content, err := client.GetContent(ids["assets/css"]); err != nil || content.Name != "css" || content.ParentFolder != ids["assets"] {
+		t.Fatalf("unexpected folder: %+v, %v", content, err)
+	}
+
+	// the files of a zip.Reader can not be rewound
+	buf := &bytes.Buffer{}
+	zipWriter := zip.NewWriter(buf)
+	for _, name := range []string{"a.txt", "dir/b.txt"} {
+		w, err := zipWriter.Create(name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		w.Write([]byte(name))
+	}
+	zipWriter.Close()
+	zipReader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	folder, err := client.CreateFolder(account.RootFolder)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	uploadedDir, err = client.UploadFS(zipReader, ".", folder.FolderId)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ids = uploadedDir.Ids()
+	if uploadedDir.FolderId != folder.FolderId {
+		t.Fatalf("unexpected folder: %s", uploadedDir.FolderId)
+	}
+	if data, _ := server.FileData(ids["dir/b.txt"]); string(data) != "dir/b.txt" {
+		t.Fatalf("unexpected data: %q", data)
+	}
+}
@@ -0,0 +1,52 @@
+package gofile_test
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"testing"
+
+	"github.com/dvwzj/gofile/gofiletest"
+	"github.com/dvwzj/gofile/params"
+)
+
+func TestUploadFiles(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	files := []params.UploadFile{
+		params.WithPath("missing.txt"),
+	}
+	for i := 0; i < 10; i++ {
+		files = append(files, params.WithBytes([]byte(fmt.Sprint(i)), fmt.Sprintf("%d.txt", i)))
+	}
+	results, err := client.UploadFiles(files, params.WithWorkers(3))
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != len(files) || results[0].Err == nil || results[0].File != nil {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	parentFolder := results[1].File.ParentFolder
+	for i, result := range results[1:] {
+		if result.Err != nil {
+			t.Fatalf("unexpected error: %v", result.Err)
+		}
+		if result.File.FileName != fmt.Sprintf("%d.txt", i) || result.File.ParentFolder != parentFolder {
+			t.Fatalf("unexpected file: %+v", result.File)
+		}
+		if data, _ := server.FileData(result.File.FileId); string(data) != fmt.Sprint(i) {
+			t.Fatalf("unexpected file data: %q", data)
+		}
+	}
+	if n := server.Requests(http.MethodGet, "/servers"); n != 1 {
+		t.Fatalf("unexpected server list requests: %d", n)
+	}
+	if n := server.Requests(http.MethodHead, "/store/store1") + server.Requests(http.MethodHead, "/store/store2"); n != 2 {
+		t.Fatalf("unexpected probes: %d", n)
+	}
+}
@@ -0,0 +1,104 @@
+package gofile_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/dvwzj/gofile"
+	"github.com/dvwzj/gofile/encryption"
+	"github.com/dvwzj/gofile/entity"
+	"github.com/dvwzj/gofile/gofiletest"
+	"github.com/dvwzj/gofile/params"
+)
+
+func TestUploadLarge(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	account := server.NewAccount(entity.AccountTierPremium)
+	client, err := server.NewClient(gofile.WithToken(account.Token))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data := make([]byte, 10*1024+123)
+	rand.Read(data)
+	// the parts are read from a stream of unknown size
+	upload, err := client.UploadLarge(params.WithReader(bytes.NewBuffer(data), "disk.img"), params.WithPartSize(1024), params.WithWorkers(3))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	manifest := upload.Manifest
+	if len(manifest.Parts) != 11 || manifest.Size != int64(len(data)) || manifest.Parts[10].Size != 123 || manifest.Parts[3].FileName != "disk.img.part0004" {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+	for i, part := range manifest.Parts {
+		partData, _ := server.FileData(part.FileId)
+		if part.Index != i || !bytes.Equal(partData, data[i*1024:min((i+1)*1024, len(data))]) {
+			t.Fatalf("unexpected part %d: %+v", i, part)
+		}
+	}
+	folder, err := client.GetContent(upload.FolderId)
+	if err != nil || folder.Name != "disk.img" || folder.ParentFolder != account.RootFolder || len(folder.ChildrenIds) != 12 {
+		t.Fatalf("unexpected folder: %+v, %v", folder, err)
+	}
+
+	buf := &bytes.Buffer{}
+	downloaded, err := client.DownloadLarge(upload.FolderId, buf, params.WithDownloadWorkers(2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) || downloaded.SHA256 != manifest.SHA256 {
+		t.Fatalf("unexpected download")
+	}
+
+	// a part is deleted
+	if err := client.DeleteContent(manifest.Parts[5].FileId); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.DownloadLarge(upload.FolderId, &bytes.Buffer{}); !errors.Is(err, entity.ErrorNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the name options of the file name the folder, the parts and the manifest
+	upload, err = client.UploadLarge(params.WithBytes(data, "disk.img"), params.WithPartSize(4096), params.WithFileOptions(params.WithFileName("renamed.img")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if upload.Manifest.FileName != "renamed.img" || upload.Manifest.Parts[0].FileName != "renamed.img.part0001" {
+		t.Fatalf("unexpected manifest: %+v", upload.Manifest)
+	}
+	buf.Reset()
+	if _, err := client.DownloadLarge(upload.FolderId, buf); err != nil || !bytes.Equal(buf.Bytes(), data) {
+		t.Fatalf("unexpected download: %v", err)
+	}
+	keys := encryption.StaticKey(bytes.Repeat([]byte{1}, 32))
+	_, err = client.UploadLarge(params.WithBytes(data, "disk.img"), params.WithFileOptions(params.WithEncryption(keys), params.WithEncryptedFileName()))
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	_, err = client.UploadLarge(params.WithBytes(data, "disk.img"), params.WithFileOptions(params.WithEncryption(keys)))
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	for _, option := range []params.DownloadOption{params.WithDecryption(keys), params.WithResumeAttempts(2)} {
+		if _, err := client.DownloadLarge(upload.FolderId, &bytes.Buffer{}, option); err == nil {
+			t.Fatalf("expected an error")
+		}
+	}
+
+	server.CorruptNext(1)
+	_, err = client.UploadLarge(params.WithBytes(data, "corrupt.img"), params.WithPartSize(4096), params.WithFileOptions(params.WithFolderId(account.RootFolder)))
+	if !errors.Is(err, entity.ErrChecksumMismatch) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	root, err := client.GetContent(account.RootFolder)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, folder := range root.Children.Folders() {
+		if folder.Name == "corrupt.img" {
+			t.Fatalf("folder of the failed upload was not deleted")
+		}
+	}
+}
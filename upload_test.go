@@ -1,15 +1,33 @@
 package gofile_test
 
 import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/dvwzj/gofile"
+	"github.com/dvwzj/gofile/encryption"
 	"github.com/dvwzj/gofile/entity"
+	"github.com/dvwzj/gofile/gofiletest"
 	"github.com/dvwzj/gofile/params"
+	"github.com/dvwzj/gofile/selector"
 )
 
 func TestUpload(t *testing.T) {
-	client, err := gofile.NewClient()
+	server := gofiletest.NewServer()
+	defer server.Close()
+	client, err := server.NewClient()
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -31,13 +49,15 @@ func TestUpload(t *testing.T) {
 	if err == nil {
 		t.Fatalf("unexpected nil error")
 	}
-	if err != entity.ErrToken {
+	if !errors.Is(err, entity.ErrToken) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
 
 func TestAsGuest(t *testing.T) {
-	client, err := gofile.NewClient(gofile.WithNewGuestAccount)
+	server := gofiletest.NewServer()
+	defer server.Close()
+	client, err := server.NewClient(gofile.WithNewGuestAccount)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -62,14 +82,22 @@ func TestAsGuest(t *testing.T) {
 }
 
 func TestUploadWithToken(t *testing.T) {
-	client, err := gofile.NewClient(gofile.WithToken("7JyBbtDTF7yakfcfmTWYlXNTL4j5r9HV"))
+	server := gofiletest.NewServer()
+	defer server.Close()
+	server.AddAccount(entity.Account{Token: "7JyBbtDTF7yakfcfmTWYlXNTL4j5r9HV", Tier: entity.AccountTierStandard})
+	logo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("\x89PNG\r\n\x1a\n"))
+	}))
+	defer logo.Close()
+	client, err := server.NewClient(gofile.WithToken("7JyBbtDTF7yakfcfmTWYlXNTL4j5r9HV"))
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	if client == nil {
 		t.Fatalf("unexpected nil client")
 	}
-	uplodedFile, err := client.UploadFile(params.WithPath("https://gofile.io/dist/img/logo-small-70.png"))
+	uplodedFile, err := client.UploadFile(params.WithPath(logo.URL + "/dist/img/logo-small-70.png"))
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -133,7 +161,7 @@ func TestUploadWithToken(t *testing.T) {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	} else {
-		if err != entity.ErrNotPremium {
+		if !errors.Is(err, entity.ErrNotPremium) {
 			t.Fatalf("unexpected error: %v", err)
 		}
 		err = client.UpdateContent(uplodedFile.FileId, params.WithName("new-name.txt"))
@@ -146,3 +174,526 @@ func TestUploadWithToken(t *testing.T) {
 		}
 	}
 }
+
+func TestUploadServerSelector(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	server.SetServers(
+		entity.Server{Name: "store1", Zone: "eu"},
+		entity.Server{Name: "store2", Zone: "na"},
+		entity.Server{Name: "store3", Zone: "na"},
+	)
+	client, err := server.NewClient(gofile.WithServerSelector(selector.PreferZone("eu")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.UploadFile(params.WithBytes([]byte("ok"), "eu.txt")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := server.Requests(http.MethodPost, "/store/store1/contents/uploadfile"); n != 1 {
+		t.Fatalf("unexpected requests to store1: %d", n)
+	}
+	server.FailNext(http.MethodHead, "/store/store1", http.StatusBadGateway, 1)
+	server.FailNext(http.MethodHead, "/store/store2", 0, 1)
+	if _, err := client.UploadFile(params.WithBytes([]byte("ok"), "na.txt")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := server.Requests(http.MethodPost, "/store/store3/contents/uploadfile"); n != 1 {
+		t.Fatalf("unexpected requests to store3: %d", n)
+	}
+	server.FailNext(http.MethodHead, "/store/store1", http.StatusBadGateway, 1)
+	if _, err := client.UploadFile(params.WithBytes([]byte("ok"), "na.txt"), params.WithServerSelector(selector.PreferZone("eu", "na"))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := server.Requests(http.MethodPost, "/store/store1/contents/uploadfile"); n != 1 {
+		t.Fatalf("unexpected requests to store1: %d", n)
+	}
+	for _, name := range []string{"store1", "store2", "store3"} {
+		server.FailNext(http.MethodHead, "/store/"+name, http.StatusBadGateway, 1)
+	}
+	if _, err := client.UploadFile(params.WithBytes([]byte("ok"), "none.txt")); !errors.Is(err, entity.ErrNoServerAvailable) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestUploadServerCache(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	client, err := server.NewClient(gofile.WithServerCache(time.Minute), gofile.WithServerCooldown(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := client.UploadFile(params.WithBytes([]byte("ok"), "ok.txt")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if n := server.Requests(http.MethodGet, "/servers"); n != 1 {
+		t.Fatalf("unexpected server list requests: %d", n)
+	}
+	if n := server.Requests(http.MethodHead, "/store/store1"); n != 1 {
+		t.Fatalf("unexpected probes: %d", n)
+	}
+
+	server.FailNext(http.MethodPost, "/store/store2/contents/uploadfile", http.StatusBadGateway, 1)
+	if _, err := client.UploadFile(params.WithBytes([]byte("ok"), "ok.txt"), params.WithServerName("store2")); err == nil {
+		t.Fatalf("unexpected nil error")
+	}
+	health := client.ServerHealth()
+	if len(health) != 2 || health[1].Name != "store2" || health[1].Healthy || health[1].Failures != 1 || health[1].LastError == "" {
+		t.Fatalf("unexpected health: %+v", health)
+	}
+	if !health[0].Healthy || health[0].Zone != "eu" || health[0].LastLatency == 0 {
+		t.Fatalf("unexpected health: %+v", health)
+	}
+	uploads := server.Requests(http.MethodPost, "/store/store2/contents/uploadfile")
+	for i := 0; i < 3; i++ {
+		if _, err := client.UploadFile(params.WithBytes([]byte("ok"), "ok.txt")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if n := server.Requests(http.MethodPost, "/store/store2/contents/uploadfile"); n != uploads {
+		t.Fatalf("cooling server was used: %d", n-uploads)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if _, err := client.UploadFile(params.WithBytes([]byte("ok"), "ok.txt")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := server.Requests(http.MethodHead, "/store/store2"); n != 2 {
+		t.Fatalf("server was not probed again: %d", n)
+	}
+	if health := client.ServerHealth(); !health[1].Healthy || health[1].Failures != 0 {
+		t.Fatalf("unexpected health: %+v", health)
+	}
+}
+
+// slowSeeker keeps a read in flight when the server answers before the end of the body.
+type slowSeeker struct {
+	*bytes.Reader
+}
+
+func (r slowSeeker) Read(p []byte) (int, error) {
+	time.Sleep(time.Millisecond)
+	return r.Reader.Read(p)
+}
+
+// TestUploadRetryRewind fails uploads before their body is read, the last attempt still sends
+// the whole file once it is rewound.
+func TestUploadRetryRewind(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	client, err := server.NewClient(gofile.WithRetryPolicy(testRetryPolicy), gofile.WithServerSelector(selector.PreferZone("eu")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data := bytes.Repeat([]byte("rewind"), 1024*1024)
+	server.FailNext(http.MethodPost, "/store/store1/contents/uploadfile", http.StatusBadGateway, 2)
+	uploadedFile, err := client.UploadFile(params.WithReader(slowSeeker{bytes.NewReader(data)}, "rewind.txt"), params.WithServerName("store1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stored, _ := server.FileData(uploadedFile.FileId); !bytes.Equal(stored, data) {
+		t.Fatalf("unexpected file data: %d bytes", len(stored))
+	}
+}
+
+func TestUploadFailover(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	client, err := server.NewClient(gofile.WithRetryPolicy(testRetryPolicy), gofile.WithServerSelector(selector.PreferZone("eu")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	server.FailNext(http.MethodPost, "/store/store1/contents/uploadfile", 0, 3)
+	uploadedFile, err := client.UploadFile(params.WithBytes([]byte("failover"), "failover.txt"), params.WithFailover(2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(uploadedFile.Attempts) != 2 || uploadedFile.Attempts[0].Server != "store1" || uploadedFile.Attempts[0].Err == nil ||
+		uploadedFile.Attempts[1].Server != "store2" || uploadedFile.Attempts[1].Err != nil {
+		t.Fatalf("unexpected attempts: %+v", uploadedFile.Attempts)
+	}
+	if data, _ := server.FileData(uploadedFile.FileId); !bytes.Equal(data, []byte("failover")) {
+		t.Fatalf("unexpected file data: %q", data)
+	}
+
+	server.FailNext(http.MethodPost, "/store/store1/contents/uploadfile", http.StatusBadGateway, 3)
+	server.FailNext(http.MethodPost, "/store/store2/contents/uploadfile", http.StatusBadGateway, 3)
+	_, err = client.UploadFile(params.WithBytes([]byte("failover"), "failover.txt"), params.WithFailover(2))
+	var uploadErr *entity.UploadError
+	if !errors.As(err, &uploadErr) || len(uploadErr.Attempts) != 2 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var apiErr *entity.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a stream can not be sent again, it stays on the first server
+	uploads := server.Requests(http.MethodPost, "/store/store2/contents/uploadfile")
+	server.FailNext(http.MethodPost, "/store/store1/contents/uploadfile", 0, 1)
+	_, err = client.UploadFile(params.WithReader(bytes.NewBufferString("stream"), "stream.txt"), params.WithFailover(2))
+	if err == nil || errors.As(err, &uploadErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := server.Requests(http.MethodPost, "/store/store2/contents/uploadfile"); n != uploads {
+		t.Fatalf("stream failed over: %d", n-uploads)
+	}
+
+	// an api error that is not transient is not sent to another server
+	_, err = client.UploadFile(params.WithBytes([]byte("failover"), "failover.txt"), params.WithFolderId("missing"), params.WithFailover(2))
+	if !errors.As(err, &uploadErr) || len(uploadErr.Attempts) != 1 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestUploadChecksum(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	account := server.NewAccount(entity.AccountTierStandard)
+	client, err := server.NewClient(gofile.WithToken(account.Token))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data := []byte("checksum")
+	uploadedFile, err := client.UploadFile(params.WithReader(bytes.NewReader(data), "checksum.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sum := sha256.Sum256(data); uploadedFile.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Fatalf("unexpected sha256: %s", uploadedFile.SHA256)
+	}
+
+	server.CorruptNext(1)
+	_, err = client.UploadFile(params.WithBytes(data, "checksum.txt"))
+	var checksumErr *entity.ChecksumError
+	if !errors.Is(err, entity.ErrChecksumMismatch) || !errors.As(err, &checksumErr) || checksumErr.Deleted {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := server.FileData(checksumErr.FileId); !ok {
+		t.Fatalf("file was deleted")
+	}
+
+	server.CorruptNext(1)
+	_, err = client.UploadFile(params.WithBytes(data, "checksum.txt"), params.WithDeleteOnChecksumMismatch())
+	if !errors.As(err, &checksumErr) || !checksumErr.Deleted {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := server.FileData(checksumErr.FileId); ok {
+		t.Fatalf("file was not deleted")
+	}
+
+	// a guest upload is deleted with its guest token
+	guest, err := server.NewClient()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	server.CorruptNext(1)
+	_, err = guest.UploadFile(params.WithBytes(data, "guest.txt"), params.WithDeleteOnChecksumMismatch())
+	if !errors.As(err, &checksumErr) || !checksumErr.Deleted {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := server.FileData(checksumErr.FileId); ok {
+		t.Fatalf("file was not deleted")
+	}
+}
+
+func TestUploadSkipIfExists(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	account := server.NewAccount(entity.AccountTierPremium)
+	client, err := server.NewClient(gofile.WithToken(account.Token))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	folder, err := client.CreateFolder(account.RootFolder, params.WithFolderName("nightly"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	uploaded, err := client.UploadFile(params.WithBytes([]byte("report"), "report.txt"), params.WithFolderId(folder.FolderId))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	uploads := func() int {
+		return server.Requests(http.MethodPost, "/store/store1/contents/uploadfile") + server.Requests(http.MethodPost, "/store/store2/contents/uploadfile")
+	}
+	before := uploads()
+
+	existing, err := client.UploadFile(params.WithBytes([]byte("changed"), "report.txt"), params.WithFolderId(folder.FolderId), params.WithSkipIfExists(params.ByName))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !existing.Existing || existing.FileId != uploaded.FileId || existing.MD5 != uploaded.MD5 || existing.ParentFolder != folder.FolderId || existing.Code != folder.Code {
+		t.Fatalf("unexpected file: %+v", existing)
+	}
+	existing, err = client.UploadFile(params.WithBytes([]byte("report"), "copy.txt"), params.WithFolderId(folder.FolderId), params.WithSkipIfExists(params.ByMD5))
+	if err != nil || !existing.Existing || existing.FileId != uploaded.FileId {
+		t.Fatalf("unexpected file: %+v, %v", existing, err)
+	}
+	if n := uploads(); n != before {
+		t.Fatalf("existing file was uploaded: %d", n-before)
+	}
+
+	changed, err := client.UploadFile(params.WithBytes([]byte("changed"), "report.txt"), params.WithFolderId(folder.FolderId), params.WithSkipIfExists(params.ByName|params.ByMD5))
+	if err != nil || changed.Existing || changed.FileId == uploaded.FileId {
+		t.Fatalf("unexpected file: %+v, %v", changed, err)
+	}
+	_, err = client.UploadFile(params.WithReader(bytes.NewBufferString("report"), "report.txt"), params.WithFolderId(folder.FolderId), params.WithSkipIfExists(params.ByMD5))
+	if err == nil {
+		t.Fatalf("unexpected nil error")
+	}
+
+	before = uploads()
+	key := encryption.StaticKey(bytes.Repeat([]byte{1}, 32))
+	for _, options := range [][]params.UploadFileOption{
+		{params.WithSkipIfExists(params.ByName)},
+		{params.WithFolderId(folder.FolderId), params.WithSkipIfExists(params.ByMD5), params.WithEncryption(key)},
+		{params.WithFolderId(folder.FolderId), params.WithSkipIfExists(params.ByName), params.WithEncryption(key), params.WithEncryptedFileName()},
+	} {
+		if _, err := client.UploadFile(params.WithBytes([]byte("report"), "report.txt"), options...); err == nil {
+			t.Fatalf("unexpected nil error")
+		}
+	}
+	if n := uploads(); n != before {
+		t.Fatalf("file was uploaded: %d", n-before)
+	}
+}
+
+func TestUploadEncryption(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	keys := encryption.StaticKey(bytes.Repeat([]byte{7}, 32))
+	data := bytes.Repeat([]byte("customer data "), 10000)
+	uploadedFile, err := client.UploadFile(params.WithBytes(data, "customers.csv"), params.WithEncryption(keys), params.WithEncryptedFileName())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name, err := encryption.DecryptFileName(uploadedFile.FileName, keys); err != nil || name != "customers.csv" {
+		t.Fatalf("unexpected file name %s: %s, %v", uploadedFile.FileName, name, err)
+	}
+	stored, _ := server.FileData(uploadedFile.FileId)
+	if bytes.Contains(stored, []byte("customer data")) {
+		t.Fatalf("data is not encrypted")
+	}
+	if sum := md5.Sum(stored); uploadedFile.MD5 != hex.EncodeToString(sum[:]) {
+		t.Fatalf("unexpected md5: %s", uploadedFile.MD5)
+	}
+	r, err := encryption.NewDecryptReader(bytes.NewReader(stored), keys)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decrypted, err := io.ReadAll(r); err != nil || !bytes.Equal(decrypted, data) {
+		t.Fatalf("unexpected data: %v", err)
+	}
+	if _, err := client.UploadFile(params.WithBytes(data, "customers.csv"), params.WithEncryptedFileName()); err == nil {
+		t.Fatalf("unexpected nil error")
+	}
+}
+
+func TestUploadAttributes(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expiry := time.Now().Add(24 * time.Hour)
+	// as guest, the attributes are set with the guest token of the upload
+	uploadedFile, err := client.UploadFile(params.WithBytes([]byte("ok"), "ok.txt"),
+		params.WithUploadDescription("nightly build"),
+		params.WithUploadTags([]string{"nightly", "linux"}),
+		params.WithUploadPublic(false),
+		params.WithUploadExpiry(expiry),
+		params.WithUploadPassword("secret"),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	attributes, _ := server.FolderAttributes(uploadedFile.ParentFolder)
+	expected := map[string]string{
+		"description": "nightly build",
+		"tags":        "nightly,linux",
+		"public":      "false",
+		"expiry":      strconv.FormatInt(expiry.Unix(), 10),
+		"password":    "secret",
+	}
+	if !reflect.DeepEqual(attributes, expected) {
+		t.Fatalf("unexpected attributes: %v", attributes)
+	}
+
+	account := server.NewAccount(entity.AccountTierStandard)
+	client, err = server.NewClient(gofile.WithToken(account.Token))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// without folder, the attributes are set on the folder created for the upload, not the root folder
+	uploadedFile, err = client.UploadFile(params.WithBytes([]byte("ok"), "ok.txt"), params.WithUploadDescription("created"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	attributes, _ = server.FolderAttributes(uploadedFile.ParentFolder)
+	rootAttributes, _ := server.FolderAttributes(account.RootFolder)
+	if uploadedFile.ParentFolder == account.RootFolder || attributes["description"] != "created" || rootAttributes["description"] != "" {
+		t.Fatalf("unexpected attributes: %v, root %v", attributes, rootAttributes)
+	}
+
+	server.FailNext(http.MethodPut, "/contents/"+account.RootFolder+"/update", http.StatusBadRequest, 1)
+	_, err = client.UploadFile(params.WithBytes([]byte("ok"), "ok.txt"), params.WithFolderId(account.RootFolder), params.WithUploadDescription("root"))
+	var postUploadErr *entity.PostUploadError
+	if !errors.As(err, &postUploadErr) || postUploadErr.Attribute != "description" || postUploadErr.FolderId != account.RootFolder {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := server.FileData(postUploadErr.File.FileId); string(data) != "ok" {
+		t.Fatalf("unexpected file data: %q", data)
+	}
+}
+
+func TestUploadStreaming(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	data := bytes.Repeat([]byte("0123456789abcdef"), 256*1024)
+	path := filepath.Join(t.TempDir(), "big.bin")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lengths := []int64{}
+	recorder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lengths = append(lengths, r.ContentLength)
+		server.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer recorder.Close()
+	client, err := gofile.NewClient(gofile.WithBaseURL(server.URL), gofile.WithUploadURLTemplate(recorder.URL+"/store/{server}"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	uploadedFile, err := client.UploadFile(params.WithPath(path), params.WithServerName("store1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if uploadedFile.FileName != "big.bin" {
+		t.Fatalf("unexpected file name: %s", uploadedFile.FileName)
+	}
+	if stored, _ := server.FileData(uploadedFile.FileId); !bytes.Equal(stored, data) {
+		t.Fatalf("unexpected file data: %d bytes", len(stored))
+	}
+	if len(lengths) != 1 || lengths[0] <= int64(len(data)) {
+		t.Fatalf("unexpected content length: %v", lengths)
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.Write(data)
+		pw.Close()
+	}()
+	uploadedFile, err = client.UploadFile(params.WithReader(pr, "piped.bin"), params.WithServerName("store1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stored, _ := server.FileData(uploadedFile.FileId); !bytes.Equal(stored, data) {
+		t.Fatalf("unexpected file data: %d bytes", len(stored))
+	}
+	if len(lengths) != 2 || lengths[1] != -1 {
+		t.Fatalf("unexpected content length: %v", lengths)
+	}
+
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Disposition", `attachment; filename="remote.bin"`)
+		for i := 0; i < len(data); i += 64 * 1024 {
+			w.Write(data[i : i+64*1024])
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer remote.Close()
+	uploadedFile, err = client.UploadFile(params.WithPath(remote.URL+"/download?id=1"), params.WithServerName("store1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if uploadedFile.FileName != "remote.bin" {
+		t.Fatalf("unexpected file name: %s", uploadedFile.FileName)
+	}
+	if stored, _ := server.FileData(uploadedFile.FileId); !bytes.Equal(stored, data) {
+		t.Fatalf("unexpected file data: %d bytes", len(stored))
+	}
+}
+
+func TestUploadProgress(t *testing.T) {
+	server := gofiletest.NewServer()
+	defer server.Close()
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data := bytes.Repeat([]byte("progress"), 128*1024)
+	path := filepath.Join(t.TempDir(), "progress.bin")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer file.Close()
+	sources := []struct {
+		name  string
+		file  params.UploadFile
+		total int64
+	}{
+		{"bytes", params.WithBytes(data, "progress.bin"), int64(len(data))},
+		{"reader", params.WithReader(io.MultiReader(bytes.NewReader(data)), "progress.bin"), -1},
+		{"path", params.WithPath(path), int64(len(data))},
+		{"file", params.WithFile(file), int64(len(data))},
+	}
+	for _, source := range sources {
+		reports := []params.Progress{}
+		_, err := client.UploadFile(source.file, params.WithServerName("store1"), params.WithProgress(func(progress params.Progress) {
+			reports = append(reports, progress)
+		}), params.WithProgressInterval(time.Hour))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", source.name, err)
+		}
+		if len(reports) != 1 {
+			t.Fatalf("%s: unexpected reports: %+v", source.name, reports)
+		}
+		last := reports[0]
+		if !last.Done || last.BytesSent != int64(len(data)) || last.TotalBytes != source.total || last.AverageSpeed <= 0 {
+			t.Fatalf("%s: unexpected progress: %+v", source.name, last)
+		}
+		if (source.total < 0 && last.ETA != -1) || (source.total >= 0 && last.ETA != 0) {
+			t.Fatalf("%s: unexpected eta: %v", source.name, last.ETA)
+		}
+	}
+
+	reports := []params.Progress{}
+	slow := &slowReader{reader: bytes.NewReader(data[:64*1024]), delay: 5 * time.Millisecond}
+	_, err = client.UploadFile(params.WithReader(slow, "slow.bin"), params.WithServerName("store1"), params.WithProgress(func(progress params.Progress) {
+		reports = append(reports, progress)
+	}), params.WithProgressInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(reports) < 2 || len(reports) > 20 {
+		t.Fatalf("unexpected number of reports: %d", len(reports))
+	}
+	for i := 1; i < len(reports); i++ {
+		if reports[i].BytesSent < reports[i-1].BytesSent {
+			t.Fatalf("progress went backwards: %+v", reports)
+		}
+	}
+}
+
+type slowReader struct {
+	reader io.Reader
+	delay  time.Duration
+}
+
+func (r *slowReader) Read(b []byte) (int, error) {
+	time.Sleep(r.delay)
+	if len(b) > 4096 {
+		b = b[:4096]
+	}
+	return r.reader.Read(b)
+}